@@ -1,3 +1,13 @@
 module github.com/uncomfyhalomacro/pokedexcli
 
 go 1.24.5
+
+require (
+	github.com/peterh/liner v1.2.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/mattn/go-runewidth v0.0.3 // indirect
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
+)
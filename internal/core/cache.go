@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+var cacheSubcommands = []string{"clear", "evict", "export", "import", "list", "stats", "warm"}
+
+// What `cache warm` can fetch ahead of time.
+var warmTargets = []string{"areas", "locations", "pokemon"}
+
+// `cache stats|list|clear|evict <key>|export <file>|import <file>|warm <what>`
+func cacheCommand(ctx context.Context, config *Config, args ...string) (Result, error) {
+	cache, err := inspectableCache(config)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: Arguments keep their case for file paths, the subcommand does not care.
+	args = append([]string{strings.ToLower(args[0])}, args[1:]...)
+	if args[0] == "warm" && len(args) > 1 {
+		return warmCache(ctx, config, strings.ToLower(args[1]), args[2:]...)
+	}
+	switch {
+	case args[0] == "stats" && len(args) == 1:
+		return CacheStatsResult{Stats: cache.Stats()}, nil
+	case args[0] == "list" && len(args) == 1:
+		keys := cache.Keys()
+		sort.Strings(keys)
+		return CacheKeysResult{Keys: keys}, nil
+	case args[0] == "clear" && len(args) == 1:
+		cleared := cache.Len()
+		cache.Clear()
+		return MessageResult{Message: fmt.Sprintf("Removed %d cache entries.", cleared)}, nil
+	case args[0] == "evict" && len(args) == 2:
+		key := cacheKey(config, args[1])
+		if !cache.Delete(key) {
+			return nil, fmt.Errorf("error, %s is not cached. See `cache list`", args[1])
+		}
+		return MessageResult{Message: fmt.Sprintf("Removed %s from the cache.", key)}, nil
+	case args[0] == "export" && len(args) == 2:
+		return exportCache(config, cache, args[1])
+	case args[0] == "import" && len(args) == 2:
+		return importCache(config, cache, args[1])
+	}
+	cmd, _ := DefaultRegistry.Lookup("cache")
+	return nil, &UsageError{Command: cmd.Name, Usage: cmd.UsageLine()}
+}
+
+func exportCache(config *Config, cache pokecache.InspectableCache, path string) (Result, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error, could not export the cache: %w", err)
+	}
+	exported, err := pokecache.ExportBundle(f, cache, config.Client.BaseURL())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("error, could not export the cache to %s: %w", path, err)
+	}
+	return MessageResult{Message: fmt.Sprintf("Exported %d cache entries to %s.", exported, path)}, nil
+}
+
+func importCache(config *Config, cache pokecache.Cache, path string) (Result, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error, could not import the cache: %w", err)
+	}
+	defer f.Close()
+	imported, err := pokecache.ImportBundle(f, cache, config.Client.BaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("error, could not import %s after %d entries: %w", path, imported, err)
+	}
+	return MessageResult{Message: fmt.Sprintf("Imported %d cache entries from %s.", imported, path)}, nil
+}
+
+// Fetches "what" so it ends up in the cache, e.g. to export it afterwards. Pages are
+// fetched with the page size `map` uses, or they would be cached under other URLs.
+func warmCache(ctx context.Context, config *Config, what string, names ...string) (Result, error) {
+	var fetched int
+	var err error
+	switch {
+	case what == "locations" && len(names) == 0:
+		fetched, _, err = warmLocationPages(ctx, config)
+	case what == "areas" && len(names) == 0:
+		var areas []string
+		fetched, areas, err = warmLocationPages(ctx, config)
+		_, errs := fetchAll(ctx, config.concurrency(), len(areas), func(ctx context.Context, i int) (pokeapi.LocationEncounterDetails, error) {
+			return config.Client.GetLocationArea(ctx, areas[i])
+		})
+		fetched += countSuccesses(errs)
+		err = errors.Join(append([]error{err}, errs...)...)
+	case what == "pokemon" && len(names) > 0:
+		_, errs := fetchAll(ctx, config.concurrency(), len(names), func(ctx context.Context, i int) (pokeapi.PokemonDetails, error) {
+			return config.Client.GetPokemon(ctx, strings.ToLower(names[i]))
+		})
+		fetched = countSuccesses(errs)
+		err = errors.Join(errs...)
+	default:
+		cmd, _ := DefaultRegistry.Lookup("cache")
+		return nil, &UsageError{Command: cmd.Name, Usage: cmd.UsageLine()}
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error, cached %d responses but some failed: %w", fetched, err)
+	}
+	return MessageResult{Message: fmt.Sprintf("Cached %d responses.", fetched)}, nil
+}
+
+// Every page `map` can show. Returns how many pages were fetched and the names of the
+// areas on them.
+func warmLocationPages(ctx context.Context, config *Config) (int, []string, error) {
+	pageSize := config.pages().PageSize
+	first, err := config.Client.GetLocationAreas(ctx, 0, pageSize)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error, could not fetch the first page of location areas: %w", err)
+	}
+	pages := max((first.Count+pageSize-1)/pageSize, 1)
+	rest, errs := fetchAll(ctx, config.concurrency(), pages-1, func(ctx context.Context, i int) (pokeapi.LocationAreas, error) {
+		return config.Client.GetLocationAreas(ctx, (i+1)*pageSize, pageSize)
+	})
+	var areas []string
+	for i, page := range append([]pokeapi.LocationAreas{first}, rest...) {
+		if i > 0 && errs[i-1] != nil {
+			continue
+		}
+		for _, area := range page.Results {
+			areas = append(areas, area.Name)
+		}
+	}
+	return 1 + countSuccesses(errs), areas, errors.Join(errs...)
+}
+
+func countSuccesses(errs []error) int {
+	n := 0
+	for _, err := range errs {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func inspectableCache(config *Config) (pokecache.InspectableCache, error) {
+	if config.Client == nil || config.Client.Cache() == nil {
+		return nil, fmt.Errorf("error, caching is disabled")
+	}
+	cache, ok := config.Client.Cache().(pokecache.InspectableCache)
+	if !ok {
+		return nil, fmt.Errorf("error, this cache cannot be looked into")
+	}
+	return cache, nil
+}
+
+// Keys are full URLs. Anything else is taken as relative to the API's base URL.
+func cacheKey(config *Config, key string) string {
+	if strings.Contains(key, "://") {
+		return key
+	}
+	return config.Client.BaseURL() + "/" + strings.TrimPrefix(key, "/")
+}
+
+type CacheStatsResult struct {
+	pokecache.Stats
+}
+
+func (r CacheStatsResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Backend:     %s\n", r.Backend)
+	fmt.Fprintf(w, "Entries:     %d (%d in memory", r.Entries, r.MemoryEntries)
+	if r.Persistent {
+		fmt.Fprintf(w, ", %d on disk", r.DiskEntries)
+	}
+	fmt.Fprintln(w, ")")
+	fmt.Fprintf(w, "Hits:        %d\n", r.Hits)
+	fmt.Fprintf(w, "Misses:      %d\n", r.Misses)
+	fmt.Fprintf(w, "Evictions:   %d\n", r.Evictions)
+	fmt.Fprintf(w, "Revalidated: %d\n", r.Revalidations)
+	fmt.Fprintf(w, "Stale hits:  %d\n", r.StaleHits)
+	fmt.Fprintf(w, "Size:        %s in memory", formatBytes(r.MemoryBytes))
+	if r.Persistent {
+		fmt.Fprintf(w, ", %s on disk", formatBytes(r.DiskBytes))
+	}
+	if r.MaxBytes > 0 {
+		fmt.Fprintf(w, " (limit %s)", formatBytes(r.MaxBytes))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Compression: %s", r.Compression)
+	if r.CompressedBytes < r.UncompressedBytes {
+		fmt.Fprintf(w, ", %s down to %s (%.1fx)", formatBytes(r.UncompressedBytes), formatBytes(r.CompressedBytes), r.CompressionRatio())
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func (r CacheStatsResult) Table() ([]string, [][]string) {
+	return []string{"BACKEND", "ENTRIES", "HITS", "MISSES", "EVICTIONS", "REVALIDATED", "STALE HITS", "MEMORY BYTES", "DISK BYTES", "COMPRESSION", "RATIO"}, [][]string{{
+		r.Backend,
+		strconv.Itoa(r.Entries),
+		strconv.FormatInt(r.Hits, 10),
+		strconv.FormatInt(r.Misses, 10),
+		strconv.FormatInt(r.Evictions, 10),
+		strconv.FormatInt(r.Revalidations, 10),
+		strconv.FormatInt(r.StaleHits, 10),
+		strconv.FormatInt(r.MemoryBytes, 10),
+		strconv.FormatInt(r.DiskBytes, 10),
+		r.Compression,
+		strconv.FormatFloat(r.CompressionRatio(), 'f', 2, 64),
+	}}
+}
+
+type CacheKeysResult struct {
+	Keys []string `json:"keys"`
+}
+
+func (r CacheKeysResult) WriteText(w io.Writer) error {
+	for _, key := range r.Keys {
+		if _, err := fmt.Fprintln(w, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r CacheKeysResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, key := range r.Keys {
+		rows = append(rows, []string{key})
+	}
+	return []string{"KEY"}, rows
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -1,395 +1,268 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
-	"log"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"io/fs"
 	"math/rand"
-	"net/http"
 	"os"
+	"strconv"
 	"strings"
 )
 
-const baseURL = "https://pokeapi.co/api/v2"
-
-var pkCache = pokecache.DefaultPokeCache()
-var capturedPokemons = map[string]PokemonDetails{}
-
-func RunSupportedCommand(config *Config, cmd string, args ...string) error {
-	command, ok := supportedCommands[cmd]
-	if !ok {
-		return fmt.Errorf("command not found: %s\n", cmd)
+// Runs the command and renders its result to the config's output in the configured
+// format. The result is rendered even when there is an error, e.g. the goodbye of `exit`.
+func RunSupportedCommand(ctx context.Context, config *Config, cmd string, args ...string) error {
+	result, err := DefaultRegistry.Run(ctx, config, cmd, args...)
+	if result != nil {
+		if renderErr := Render(config.Out(), config.Output, result); renderErr != nil {
+			return renderErr
+		}
 	}
-	callback := command.callback
-	err := callback(config, args...)
 	return err
 }
 
-func commandExit(_ *Config, _ ...string) error {
-	fmt.Println("Closing the Pokedex... Goodbye!")
-	os.Exit(0)
-	return fmt.Errorf("error, there was an error exiting the program")
+func commandExit(_ context.Context, _ *Config, _ ...string) (Result, error) {
+	return MessageResult{Message: "Closing the Pokedex... Goodbye!"}, ErrExit
 }
 
-func displayHelp(_ *Config, _ ...string) error {
-	fmt.Printf("Welcome to the Pokedex!\nUsage:\n\n")
-	var display string
-	for commandName, fieldNames := range supportedCommands {
-		display = fmt.Sprintf("%s: %s\n", commandName, fieldNames.description)
-		fmt.Print(display)
-	}
-	return nil
-}
-
-func mapNextPage(config *Config, _ ...string) error {
-	var locationData LocationAreas
-	fullURL := baseURL + "/location-area"
-	Previous := &(*config).Previous
-	Next := &(*config).Next
-	if *Next != "" {
-		fullURL = *Next
-	}
-	cachedData, ok := (*pkCache).Get(*Next)
-	if !ok {
-		resp, err := http.Get(fullURL)
-		if err != nil {
-			return fmt.Errorf("error, there was a problem getting map information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if resp.StatusCode > 299 {
-			log.Fatalf("Response failed with status code: %d and\nbody: %s\nfull url: %s", resp.StatusCode, resp.Body, fullURL)
-		}
-
-		decoder := json.NewDecoder(resp.Body)
-		err = decoder.Decode(&locationData)
-
-		if err != nil {
-			return fmt.Errorf("error, there was a problem generating map information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if len(locationData.Results) == 0 || locationData.Results == nil {
-			return fmt.Errorf("error, map location is empty! Status: %s", resp.Status)
-		}
-		for _, location := range locationData.Results {
-			fmt.Println(location.Name)
-		}
-		nextList, ok := locationData.Next.(string)
-		if !ok {
-			nextList = baseURL + "/location-area" // round trip
-		}
-
-		*Next = nextList
-
-		previousList, ok := locationData.Previous.(string)
+func displayHelp(_ context.Context, _ *Config, args ...string) (Result, error) {
+	result := HelpResult{registry: DefaultRegistry}
+	if len(args) == 1 {
+		cmd, ok := DefaultRegistry.Lookup(args[0])
 		if !ok {
-			previousList = ""
+			return nil, commandNotFound(args[0])
 		}
-		*Previous = previousList
-
-		byteData, err := json.Marshal(locationData)
-		if err != nil {
-			return fmt.Errorf("error, failed to convert body as byte data")
+		result.command = args[0]
+		result.Commands = []CommandInfo{newCommandInfo(cmd)}
+		return result, nil
+	}
+	categories, grouped := DefaultRegistry.byCategory()
+	for _, category := range categories {
+		for _, cmd := range grouped[category] {
+			result.Commands = append(result.Commands, newCommandInfo(cmd))
 		}
-		(*pkCache).Add(fullURL, byteData)
-		resp.Body.Close()
-	} else {
-		err := json.Unmarshal(cachedData, &locationData)
+	}
+	return result, nil
+}
 
+// `map` shows the next page, `map first`, `map last` and `map page <n>` jump.
+func mapNextPage(ctx context.Context, config *Config, args ...string) (Result, error) {
+	pages := config.pages()
+	var index int
+	var err error
+	switch {
+	case len(args) == 0:
+		index, err = pages.next()
+	case args[0] == "first" && len(args) == 1:
+		index = 0
+	case args[0] == "last" && len(args) == 1:
+		index, err = pages.last(ctx, config.Client)
+	case args[0] == "page" && len(args) == 2:
+		var page int
+		page, err = strconv.Atoi(args[1])
 		if err != nil {
-			return fmt.Errorf("error, there was a problem generating map information from cache: %w\n", err)
-		}
-
-		if len(locationData.Results) == 0 || locationData.Results == nil {
-			return fmt.Errorf("error, map location is empty from cache!")
-		}
-		for _, location := range locationData.Results {
-			fmt.Println(location.Name)
-		}
-		nextList, ok := locationData.Next.(string)
-		if !ok {
-			nextList = baseURL + "/location-area" // round trip
-		}
-
-		*Next = nextList
-
-		previousList, ok := locationData.Previous.(string)
-		if !ok {
-			previousList = baseURL + "/location-area"
+			return nil, invalidArgument("error, %s is not a page number", args[1])
 		}
-		*Previous = previousList
-		(*pkCache).Add(fullURL, cachedData)
+		index, err = pages.index(page)
+	default:
+		cmd, _ := DefaultRegistry.Lookup("map")
+		return nil, &UsageError{Command: cmd.Name, Usage: cmd.UsageLine()}
 	}
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	return showLocationPage(ctx, config, index)
 }
 
-func mapPreviousPage(config *Config, _ ...string) error {
-	var locationData LocationAreas
-	fullURL := baseURL + "/location-area"
-	Previous := &(*config).Previous
-	Next := &(*config).Next
-	if *Previous != "" {
-		fullURL = *Previous
+func mapPreviousPage(ctx context.Context, config *Config, _ ...string) (Result, error) {
+	index, err := config.pages().previous()
+	if err != nil {
+		return nil, err
 	}
-	cachedData, ok := (*pkCache).Get(*Next)
-	if !ok {
-		resp, err := http.Get(fullURL)
-		if err != nil {
-			return fmt.Errorf("error, there was a problem getting map information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if resp.StatusCode > 299 {
-			log.Fatalf("Response failed with status code: %d and\nbody: %s\n", resp.StatusCode, resp.Body)
-		}
-
-		decoder := json.NewDecoder(resp.Body)
-		err = decoder.Decode(&locationData)
-
-		if err != nil {
-			return fmt.Errorf("error, there was a problem generating map information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if len(locationData.Results) == 0 || locationData.Results == nil {
-			return fmt.Errorf("error, map location is empty! Status: %s", resp.Status)
-		}
-		for _, location := range locationData.Results {
-			fmt.Println(location.Name)
-		}
-		nextList, ok := locationData.Next.(string)
-		if !ok {
-			nextList = baseURL + "/location-area" // round trip
-		}
-
-		*Next = nextList
-
-		previousList, ok := locationData.Previous.(string)
-		if !ok {
-			previousList = ""
-		}
-		*Previous = previousList
-		byteData, err := json.Marshal(locationData)
-		if err != nil {
-			return fmt.Errorf("error, failed to convert body as byte data")
-		}
-		(*pkCache).Add(fullURL, byteData)
-		resp.Body.Close()
-	} else {
-
-		err := json.Unmarshal(cachedData, &locationData)
-
-		if err != nil {
-			return fmt.Errorf("error, there was a problem generating map information from cache: %w\n", err)
-		}
-
-		if len(locationData.Results) == 0 || locationData.Results == nil {
-			return fmt.Errorf("error, map location is empty from cache!")
-		}
-		for _, location := range locationData.Results {
-			fmt.Println(location.Name)
-		}
-		nextList, ok := locationData.Next.(string)
-		if !ok {
-			nextList = baseURL + "/location-area" // round trip
-		}
-
-		*Next = nextList
+	return showLocationPage(ctx, config, index)
+}
 
-		previousList, ok := locationData.Previous.(string)
-		if !ok {
-			previousList = baseURL + "/location-area"
-		}
-		*Previous = previousList
-		(*pkCache).Add(fullURL, cachedData)
+// Shared by `map` and `mapb`.
+func showLocationPage(ctx context.Context, config *Config, index int) (Result, error) {
+	pages := config.pages()
+	locationData, err := pages.fetch(ctx, config.Client, index)
+	if err != nil {
+		return nil, fmt.Errorf("error, could not get the location areas: %w", err)
+	}
+	result := LocationPageResult{
+		Count:    locationData.Count,
+		Page:     pages.Page(),
+		Pages:    pages.Pages(),
+		PageSize: pages.PageSize,
+	}
+	for _, location := range locationData.Results {
+		result.Locations = append(result.Locations, location.Name)
 	}
-	return nil
+	return result, nil
 }
 
-// This is just a wrapper around the `exploreArea` function. It receives many area as arguments
-func exploreAreas(_ *Config, areas ...string) error {
-	fullURL := baseURL + "/location-area/" // NOTE: Take note of the last slash. It's there so I don't need to append it during a for loop.
-	for _, area := range areas {
-		areaURL := fullURL + area
-		fmt.Printf("Exploring %s...\n", area)
-		err := exploreArea(areaURL)
-		if err != nil {
-			return err
-		}
+// This is just a wrapper around the `exploreArea` function. It receives many area as
+// arguments and explores them concurrently. Areas that fail are left out of the result
+// and their errors are returned together.
+func exploreAreas(ctx context.Context, config *Config, areas ...string) (Result, error) {
+	explored, errs := fetchAll(ctx, config.concurrency(), len(areas), func(ctx context.Context, i int) (AreaEncounters, error) {
+		return exploreArea(ctx, config, areas[i])
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return nil
+	var result ExploreResult
+	for i, encounters := range explored {
+		if errs[i] != nil {
+			continue
+		}
+		result.Areas = append(result.Areas, encounters)
+		// NOTE: The last area that worked, as if they were explored one after another.
+		config.Location = encounters.Area
+	}
+	return result, errors.Join(errs...)
 }
 
 // This is the original caller
-func exploreArea(url string) error {
-	cachedData, ok := (*pkCache).Get(url)
-	if !ok {
-		var areaData LocationEncounterDetails
-		resp, err := http.Get(url)
-		if err != nil {
-			return fmt.Errorf("error, there was a problem getting pokemon list information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if resp.StatusCode > 299 {
-			log.Fatalf("Response failed with status code: %d and\nbody: %s\n", resp.StatusCode, resp.Body)
-		}
-
-		decoder := json.NewDecoder(resp.Body)
-		err = decoder.Decode(&areaData)
-
-		if err != nil {
-			return fmt.Errorf("error, there was a problem generating pokemon list information: %w\nStatus: %s", err, resp.Status)
-		}
-		for _, pokemonEncounter := range areaData.PokemonEncounters {
-			fmt.Println(pokemonEncounter.Pokemon.Name)
-		}
-		byteData, err := json.Marshal(areaData.PokemonEncounters)
-		if err != nil {
-			return fmt.Errorf("error, failed to convert body as byte data")
-		}
-		(*pkCache).Add(url, byteData)
-		resp.Body.Close()
-	} else {
-		var pokemonEncounters []PokemonEncounter
-		err := json.Unmarshal(cachedData, &pokemonEncounters)
-
-		if err != nil {
-			return fmt.Errorf("error, there was a problem generating pokemon list information from cache: %w\n", err)
-		}
-
-		if len(pokemonEncounters) == 0 || pokemonEncounters == nil {
-			return fmt.Errorf("error, pokemon list is empty from cache!")
-		}
-
-		for _, pokemonEncounter := range pokemonEncounters {
-			fmt.Println(pokemonEncounter.Pokemon.Name)
-		}
-		(*pkCache).Add(url, cachedData)
+func exploreArea(ctx context.Context, config *Config, area string) (AreaEncounters, error) {
+	encounters := AreaEncounters{Area: area}
+	areaData, err := config.Client.GetLocationArea(ctx, area)
+	if err != nil {
+		return encounters, fmt.Errorf("error, could not explore %s: %w", area, err)
 	}
 
-	return nil
-}
-
-func catchPokemon(_ *Config, args ...string) error {
-	if len(args) > 1 {
-		return fmt.Errorf("error, only needs 1 argument\n")
+	if len(areaData.PokemonEncounters) == 0 {
+		return encounters, fmt.Errorf("error, %w: no pokemons to encounter in %s", ErrEmptyResult, area)
 	}
 
-	if len(args) == 0 {
-		return fmt.Errorf("error, please provide a pokemon name or ID\n")
+	for _, pokemonEncounter := range areaData.PokemonEncounters {
+		encounters.Pokemons = append(encounters.Pokemons, pokemonEncounter.Pokemon.Name)
 	}
+	return encounters, nil
+}
 
-	pokemon, err := fetchPokemonDetail(args[0])
+func catchPokemon(ctx context.Context, config *Config, args ...string) (Result, error) {
+	pokemon, err := config.Client.GetPokemon(ctx, args[0])
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error, could not catch %s: %w", args[0], err)
 	}
-	fmt.Printf("Throwing a Pokeball at %s...\n", pokemon.Name)
+	result := CatchResult{Species: pokemon.Name}
 	getChance := rand.Intn(pokemon.BaseExperience + 30)
 	if getChance >= pokemon.BaseExperience {
-		fmt.Printf("You have caught %s! 🎉\n", pokemon.Name)
-		capturedPokemons[args[0]] = pokemon
-	} else {
-		fmt.Printf("%s escaped and ran away! 😩\n", pokemon.Name)
+		caught := config.Pokedex.Add(pokemon, config.Location)
+		result.Caught = true
+		result.Pokemon = &caught
+		if err := config.Pokedex.Save(); err != nil {
+			return result, err
+		}
 	}
-	return nil
+	return result, nil
 }
 
-func pokedex(_ *Config, _ ...string) error {
-	fmt.Println("Your Pokedex:")
-	if len(capturedPokemons) == 0 {
-		return fmt.Errorf("Your Pokedex is empty... Try capuring a pokemon first.\n")
-	}
-	for k, _ := range capturedPokemons {
-		fmt.Printf("  - %s\n", k)
-	}
-	return nil
+var errEmptyPokedex = fmt.Errorf("error, %w: your Pokedex is empty. Try capturing a pokemon first", ErrEmptyResult)
 
+func pokedex(_ context.Context, config *Config, _ ...string) (Result, error) {
+	if len(config.Pokedex.Pokemons) == 0 {
+		return nil, errEmptyPokedex
+	}
+	return PokedexResult{Pokemons: config.Pokedex.Pokemons}, nil
 }
 
-func inspect(_ *Config, pokemonNames ...string) error {
-	if len(capturedPokemons) == 0 {
-		return fmt.Errorf("Your Pokedex is empty... Try capuring a pokemon first.\n")
+// Receives instance IDs, species names or nicknames. Without arguments every captured
+// pokemon is inspected.
+func inspect(ctx context.Context, config *Config, refs ...string) (Result, error) {
+	if len(config.Pokedex.Pokemons) == 0 {
+		return nil, errEmptyPokedex
 	}
-	if len(pokemonNames) == 0 {
-		for k, _ := range capturedPokemons {
-			pokemonNames = append(pokemonNames, k)
-		}
+	var result InspectResult
+	var toInspect []CaughtPokemon
+	if len(refs) == 0 {
+		toInspect = config.Pokedex.Pokemons
 	}
-	for _, pokemonName := range pokemonNames {
-		pokemon, ok := capturedPokemons[pokemonName]
-		if !ok {
-			_, err := fetchPokemonDetail(pokemonName)
-			if err != nil {
-				fmt.Printf("This pokemon species does not exist.\n")
-
-			} else {
-				fmt.Printf("It seems you have not captured %s yet.\n", pokemonName)
-			}
-		} else {
-			var stats []string
-			var types []string
-			for _, stat := range pokemon.Stats {
-				stats = append(stats, fmt.Sprintf("  -%s: %d", stat.Stat.Name, stat.BaseStat))
-			}
-			for _, type_ := range pokemon.Types {
-				types = append(types, fmt.Sprintf("  - %s", type_.Type.Name))
-			}
-
-			details := fmt.Sprintf(`Name: %s
-Height: %d
-Weight: %d
-Stats:
-%s
-Types:
-%s
-`, pokemon.Name, pokemon.Height, pokemon.Weight, strings.Join(stats, "\n"), strings.Join(types, "\n"))
-			fmt.Println(details)
-
+	var missing []string
+	for _, ref := range refs {
+		found := config.Pokedex.Lookup(ref)
+		if len(found) == 0 {
+			missing = append(missing, ref)
 		}
+		toInspect = append(toInspect, found...)
+	}
+	// NOTE: Explaining a miss may need the API, so those are looked up concurrently.
+	reasons, _ := fetchAll(ctx, config.concurrency(), len(missing), func(ctx context.Context, i int) (string, error) {
+		return notCapturedReason(ctx, config, missing[i]), nil
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, ref := range missing {
+		result.NotFound = append(result.NotFound, InspectMiss{Ref: ref, Reason: reasons[i]})
+	}
+	for _, caught := range toInspect {
+		result.Pokemons = append(result.Pokemons, InspectedPokemon{
+			CaughtPokemon: caught,
+			Details:       newPokemonSummary(config.Pokedex.Species[caught.Species]),
+		})
 	}
-	return nil
+	return result, nil
 }
 
-func fetchPokemonDetail(pokemonNameOrId string) (PokemonDetails, error) {
-	var pokemon PokemonDetails
-	fullURL := baseURL + "/pokemon/" + pokemonNameOrId
-	cachedData, ok := (*pkCache).Get(fullURL)
-	if !ok {
-		resp, err := http.Get(fullURL)
-		if err != nil {
-			return PokemonDetails{}, fmt.Errorf("error, there was a problem getting pokemon information: %w\nStatus: %s", err, resp.Status)
-		}
-
-		if resp.StatusCode > 299 {
-			return PokemonDetails{}, fmt.Errorf("Response failed with status code: %d and\nbody: %s\nPokemon species with name or ID, %s, does not exist.\n", resp.StatusCode, resp.Body, pokemonNameOrId)
-		}
-
-		decoder := json.NewDecoder(resp.Body)
-		err = decoder.Decode(&pokemon)
-
-		if err != nil {
-			return PokemonDetails{}, fmt.Errorf("error, there was a problem generating pokemon list information: %w\nStatus: %s", err, resp.Status)
-		}
-		byteData, err := json.Marshal(pokemon)
-		if err != nil {
-			return PokemonDetails{}, fmt.Errorf("error, failed to convert body as byte data")
-		}
-		(*pkCache).Add(fullURL, byteData)
-		resp.Body.Close()
-		if err != nil {
-			return PokemonDetails{}, fmt.Errorf("error, failed to convert body as byte data")
-		}
-		(*pkCache).Add(fullURL, byteData)
-		resp.Body.Close()
-
-	} else {
-		err := json.Unmarshal(cachedData, &pokemon)
+// Tells apart a species that was never caught from one that does not exist.
+func notCapturedReason(ctx context.Context, config *Config, ref string) string {
+	_, err := config.Client.GetPokemon(ctx, ref)
+	if errors.Is(err, pokeapi.ErrNotCached) {
+		return fmt.Sprintf("It seems you have not captured %s yet. No details about it are available offline.", ref)
+	} else if errors.Is(err, pokeapi.ErrNotFound) {
+		return "This pokemon species does not exist."
+	} else if err != nil {
+		return fmt.Sprintf("It seems you have not captured %s yet. Looking it up failed: %v", ref, err)
+	}
+	return fmt.Sprintf("It seems you have not captured %s yet.", ref)
+}
 
-		if err != nil {
-			return PokemonDetails{}, fmt.Errorf("error, there was a problem fetching pokemon from cache: %w\n", err)
-		}
+func nickname(_ context.Context, config *Config, args ...string) (Result, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return nil, invalidArgument("error, %s is not a pokemon ID. See `pokedex` for the IDs", args[0])
+	}
+	caught, err := config.Pokedex.SetNickname(id, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Pokedex.Save(); err != nil {
+		return nil, err
+	}
+	return NicknameResult{Pokemon: caught}, nil
+}
 
-		(*pkCache).Add(fullURL, cachedData)
+func savePokedex(_ context.Context, config *Config, _ ...string) (Result, error) {
+	if err := config.Pokedex.Save(); err != nil {
+		return nil, err
+	}
+	return SaveFileResult{Action: "save", Path: config.Pokedex.Path, Pokemons: len(config.Pokedex.Pokemons)}, nil
+}
 
+func loadPokedex(_ context.Context, config *Config, args ...string) (Result, error) {
+	path, err := expandHome(args[0])
+	if err != nil {
+		return nil, err
 	}
-	return pokemon, nil
+	// NOTE: A missing file is a new profile, but say so in case the path has a typo.
+	_, statErr := os.Stat(path)
+	pokedex, err := LoadPokedex(path)
+	if err != nil {
+		return nil, err
+	}
+	config.Pokedex = pokedex
+	return SaveFileResult{Action: "load", Path: pokedex.Path, Pokemons: len(pokedex.Pokemons), New: errors.Is(statErr, fs.ErrNotExist)}, nil
+}
 
+func resetPokedex(_ context.Context, config *Config, _ ...string) (Result, error) {
+	config.Pokedex.Reset()
+	if err := config.Pokedex.Save(); err != nil {
+		return nil, err
+	}
+	return SaveFileResult{Action: "reset", Path: config.Pokedex.Path}, nil
 }
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+// A tiny PokeAPI. Areas map to the pokemons found there. The list of areas is
+// sorted by name and paged with offset and limit like the real one.
+func newTestServer(t *testing.T, areas map[string][]string) *httptest.Server {
+	t.Helper()
+	var names []string
+	for area := range areas {
+		names = append(names, area)
+	}
+	sort.Strings(names)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/location-area" {
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			var results []string
+			for _, name := range names[min(offset, len(names)):min(offset+limit, len(names))] {
+				results = append(results, fmt.Sprintf(`{"name": %q, "url": ""}`, name))
+			}
+			fmt.Fprintf(w, `{"count": %d, "results": [%s]}`, len(names), strings.Join(results, ","))
+			return
+		}
+		area, ok := strings.CutPrefix(r.URL.Path, "/location-area/")
+		pokemons, found := areas[area]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		var encounters []string
+		for _, pokemon := range pokemons {
+			encounters = append(encounters, fmt.Sprintf(`{"pokemon": {"name": %q, "url": ""}}`, pokemon))
+		}
+		fmt.Fprintf(w, `{"name": %q, "pokemon_encounters": [%s]}`, area, strings.Join(encounters, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestConfig(t *testing.T, server *httptest.Server) (*Config, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	config := &Config{
+		Client:  pokeapi.NewClient(server.URL, server.Client(), nil),
+		Pokedex: NewPokedex(filepath.Join(t.TempDir(), "pokedex.json")),
+		Stdout:  &out,
+		Stderr:  io.Discard,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	return config, &out
+}
+
+func TestExploreWritesToConfig(t *testing.T) {
+	server := newTestServer(t, map[string][]string{
+		"canalave-city-area": {"tentacool", "tentacruel"},
+	})
+	config, out := newTestConfig(t, server)
+
+	if err := RunSupportedCommand(context.Background(), config, "explore", "canalave-city-area"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Exploring canalave-city-area...\ntentacool\ntentacruel\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+	if config.Location != "canalave-city-area" {
+		t.Errorf("expected the explored area to become the current location, got %q", config.Location)
+	}
+}
+
+func TestPokedexEmpty(t *testing.T) {
+	config, out := newTestConfig(t, newTestServer(t, nil))
+	if err := RunSupportedCommand(context.Background(), config, "pokedex"); err == nil {
+		t.Errorf("expected an error for an empty Pokedex")
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
+
+func TestLoadKeepsPathAsTyped(t *testing.T) {
+	server := newTestServer(t, nil)
+	config, out := newTestConfig(t, server)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := NewPokedex(filepath.Join(home, "Misty.json")).Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := RunSupportedCommand(context.Background(), config, "load", "~/Misty.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "Loaded 0 pokemons from " + filepath.Join(home, "Misty.json") + "\n"; out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := RunSupportedCommand(context.Background(), config, "load", "~/misty.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "There is no save file at "+filepath.Join(home, "misty.json")) {
+		t.Errorf("expected to be told the save file is new, got %q", out.String())
+	}
+}
+
+func TestExploreStopsWhenCancelled(t *testing.T) {
+	server := newTestServer(t, map[string][]string{"canalave-city-area": {"tentacool"}})
+	config, out := newTestConfig(t, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := RunSupportedCommand(ctx, config, "explore", "canalave-city-area")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled error, got: %v", err)
+	}
+	if strings.Contains(out.String(), "tentacool") {
+		t.Errorf("expected nothing to be explored, got %q", out.String())
+	}
+}
+
+func TestExploreCollectsFailures(t *testing.T) {
+	server := newTestServer(t, map[string][]string{
+		"canalave-city-area": {"tentacool"},
+		"eterna-city-area":   {"buneary"},
+	})
+	config, out := newTestConfig(t, server)
+	config.Concurrency = 2
+
+	err := RunSupportedCommand(context.Background(), config, "explore", "eterna-city-area", "nowhere", "canalave-city-area")
+	if !errors.Is(err, pokeapi.ErrNotFound) {
+		t.Errorf("expected a not found error for the missing area, got: %v", err)
+	}
+	expected := "Exploring eterna-city-area...\nbuneary\nExploring canalave-city-area...\ntentacool\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+	if config.Location != "canalave-city-area" {
+		t.Errorf("expected the last explored area to become the current location, got %q", config.Location)
+	}
+}
+
+func TestCacheCommand(t *testing.T) {
+	server := newTestServer(t, map[string][]string{"canalave-city-area": {"tentacool"}})
+	config, out := newTestConfig(t, server)
+	config.Client = pokeapi.NewClient(server.URL, server.Client(), pokecache.NewPokeCache(time.Minute, pokecache.WithoutReaping()))
+	run := func(args ...string) error {
+		out.Reset()
+		return RunSupportedCommand(context.Background(), config, "cache", args...)
+	}
+
+	RunSupportedCommand(context.Background(), config, "explore", "canalave-city-area")
+	if err := run("list"); err != nil || out.String() != server.URL+"/location-area/canalave-city-area\n" {
+		t.Errorf("expected the explored area in the list, got %q and %v", out.String(), err)
+	}
+	if err := run("evict", "location-area/canalave-city-area"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := run("evict", "location-area/canalave-city-area"); err == nil {
+		t.Errorf("expected an error for a key that is not cached")
+	}
+	if err := run("stats"); err != nil || !strings.Contains(out.String(), "Entries:     0") {
+		t.Errorf("expected no entries after evicting, got %q and %v", out.String(), err)
+	}
+	if err := run("frobnicate"); !errors.Is(err, ErrInvalidArgs) {
+		t.Errorf("expected a usage error, got: %v", err)
+	}
+}
+
+func TestCacheWarmExportImport(t *testing.T) {
+	server := newTestServer(t, map[string][]string{"canalave-city-area": {"tentacool"}, "eterna-city-area": {"psyduck"}, "mt-coronet-1f": {"zubat"}})
+	config, out := newTestConfig(t, server)
+	config.Pages = NewPaginator(2)
+	config.Client = pokeapi.NewClient(server.URL, server.Client(), pokecache.NewPokeCache(time.Minute, pokecache.WithoutReaping()))
+	if err := RunSupportedCommand(context.Background(), config, "cache", "warm", "areas"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// NOTE: Two pages of location areas and three areas.
+	if out.String() != "Cached 5 responses.\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	// NOTE: Paths keep their case, only the subcommand is case insensitive.
+	dir := filepath.Join(t.TempDir(), "Workshop")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bundle := filepath.Join(dir, "Bundle.tar.gz")
+	if err := RunSupportedCommand(context.Background(), config, "cache", "EXPORT", bundle); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// NOTE: The bundle is older than the TTL here, offline it still has to work.
+	const ttl = time.Millisecond
+	offlineCache, err := pokecache.NewPersistentPokeCache(t.TempDir(), ttl, 0, pokecache.WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	offline, out := newTestConfig(t, server)
+	offline.Pages = NewPaginator(2)
+	offline.Client = pokeapi.NewClient(server.URL, server.Client(), offlineCache)
+	offline.Client.SetOffline(true)
+	time.Sleep(2 * ttl)
+	if err := RunSupportedCommand(context.Background(), offline, "cache", "import", bundle); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RunSupportedCommand(context.Background(), offline, "map", "last"); err != nil {
+		t.Errorf("expected the imported pages to work offline, got: %v", err)
+	}
+	if err := RunSupportedCommand(context.Background(), offline, "explore", "mt-coronet-1f"); err != nil {
+		t.Errorf("expected the imported areas to work offline, got: %v", err)
+	}
+	if err := RunSupportedCommand(context.Background(), offline, "cache", "warm", "everything"); !errors.Is(err, ErrInvalidArgs) {
+		t.Errorf("expected a usage error, got: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// Candidates for the word being typed. "words" are the complete words before it,
+// the first one being the command. Only cached data is used, completion never
+// touches the network.
+func (r *Registry) Complete(config *Config, words []string, prefix string) []string {
+	if len(words) == 0 {
+		return filterPrefix(r.Names(), prefix)
+	}
+	cmd, ok := r.Lookup(words[0])
+	if !ok || cmd.Complete == nil {
+		return nil
+	}
+	args := words[1:]
+	if cmd.MaxArgs != Unlimited && len(args) >= cmd.MaxArgs {
+		return nil
+	}
+	return filterPrefix(cmd.Complete(config, args), prefix)
+}
+
+// Names and aliases of every visible command, sorted.
+func (r *Registry) Names() []string {
+	var names []string
+	for _, cmd := range r.Commands() {
+		if cmd.Hidden {
+			continue
+		}
+		names = append(names, cmd.Name)
+		names = append(names, cmd.Aliases...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+func completeCommandNames(_ *Config, _ []string) []string {
+	return DefaultRegistry.Names()
+}
+
+func completeLocationAreas(config *Config, _ []string) []string {
+	return config.Client.CachedLocationAreaNames()
+}
+
+func completePokemonNames(config *Config, _ []string) []string {
+	return config.Client.CachedPokemonNames()
+}
+
+// Captured pokemons first, by species and nickname, then whatever else is cached.
+func completeCapturedPokemons(config *Config, _ []string) []string {
+	seen := make(map[string]struct{})
+	var candidates []string
+	add := func(name string) {
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		candidates = append(candidates, name)
+	}
+	for _, caught := range config.Pokedex.Pokemons {
+		add(caught.Species)
+		add(caught.Nickname)
+	}
+	for _, name := range config.Client.CachedPokemonNames() {
+		add(name)
+	}
+	return candidates
+}
+
+func completeMapArgs(_ *Config, args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	return []string{"first", "last", "page"}
+}
+
+func completeCacheArgs(config *Config, args []string) []string {
+	if len(args) == 0 {
+		return cacheSubcommands
+	}
+	if args[0] == "warm" && len(args) == 1 {
+		return warmTargets
+	}
+	if args[0] != "evict" || len(args) > 1 || config == nil {
+		return nil
+	}
+	cache, err := inspectableCache(config)
+	if err != nil {
+		return nil
+	}
+	var keys []string
+	for _, key := range cache.Keys() {
+		keys = append(keys, strings.TrimPrefix(key, config.Client.BaseURL()+"/"))
+	}
+	sort.Strings(keys)
+	return keys
+}
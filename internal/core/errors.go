@@ -0,0 +1,46 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Returned by `exit`. The REPL stops when it sees it, so it can clean up first.
+	ErrExit            = errors.New("exit requested")
+	ErrCommandNotFound = errors.New("command not found")
+	// Wrong number of arguments, or one the command cannot use.
+	ErrInvalidArgs = errors.New("invalid arguments")
+	// A command ran fine but had nothing to show, e.g. an empty Pokedex.
+	ErrEmptyResult = errors.New("nothing to show")
+)
+
+// Returned when a command gets the wrong number of arguments. Matches ErrInvalidArgs.
+type UsageError struct {
+	Command string
+	Usage   string
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("error, wrong number of arguments for %s", e.Command)
+}
+
+func (e *UsageError) Is(target error) bool { return target == ErrInvalidArgs }
+
+func commandNotFound(name string) error {
+	return fmt.Errorf("error, %w: %s", ErrCommandNotFound, name)
+}
+
+// An argument with a value the command cannot use, e.g. a page number that is not a
+// number. Matches ErrInvalidArgs.
+type argumentError struct {
+	message string
+}
+
+func (e *argumentError) Error() string { return e.message }
+
+func (e *argumentError) Is(target error) bool { return target == ErrInvalidArgs }
+
+func invalidArgument(format string, args ...any) error {
+	return &argumentError{message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Built-in categories come first in this order. Categories added by other packages
+// follow alphabetically.
+var categoryOrder = []string{CategoryGeneral, CategoryExploration, CategoryPokedex, CategoryCache}
+
+func categoryRank(category string) int {
+	for i, c := range categoryOrder {
+		if c == category {
+			return i
+		}
+	}
+	return len(categoryOrder)
+}
+
+// Visible commands grouped by category. Both the groups and the commands inside them
+// are always in the same order.
+func (r *Registry) byCategory() ([]string, map[string][]Command) {
+	grouped := make(map[string][]Command)
+	for _, cmd := range r.Commands() {
+		if cmd.Hidden {
+			continue
+		}
+		grouped[cmd.Category] = append(grouped[cmd.Category], cmd)
+	}
+	categories := make([]string, 0, len(grouped))
+	for category := range grouped {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		ri, rj := categoryRank(categories[i]), categoryRank(categories[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return categories[i] < categories[j]
+	})
+	return categories, grouped
+}
+
+func (r *Registry) WriteHelp(w io.Writer) error {
+	categories, grouped := r.byCategory()
+	// NOTE: One width for every category so the descriptions line up across groups.
+	width := 0
+	for _, commands := range grouped {
+		for _, cmd := range commands {
+			width = max(width, len(cmd.UsageLine()))
+		}
+	}
+
+	fmt.Fprintf(w, "Welcome to the Pokedex!\nUsage:\n")
+	for _, category := range categories {
+		fmt.Fprintf(w, "\n%s:\n", category)
+		for _, cmd := range grouped[category] {
+			description := cmd.Description
+			if cmd.Experimental {
+				description += " (experimental)"
+			}
+			fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.UsageLine(), description)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nUse `help <command>` for details about a command.\n")
+	return err
+}
+
+func (r *Registry) WriteCommandHelp(w io.Writer, name string) error {
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return commandNotFound(name)
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", cmd.UsageLine(), cmd.Description)
+	if cmd.Experimental {
+		fmt.Fprintf(w, "\nThis command is experimental and may change.\n")
+	}
+	if len(cmd.Arguments) > 0 {
+		fmt.Fprintf(w, "\nArguments:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, arg := range cmd.Arguments {
+			fmt.Fprintf(tw, "  %s\t%s\n", arg.Name, arg.Description)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintf(w, "\nExamples:\n")
+		for _, example := range cmd.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+	return nil
+}
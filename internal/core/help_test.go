@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file, run the tests with -update: %v", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("output does not match %s\nexpected:\n%s\ngot:\n%s", path, expected, actual)
+	}
+}
+
+func TestHelpSnapshot(t *testing.T) {
+	var first, second bytes.Buffer
+	if err := DefaultRegistry.WriteHelp(&first); err != nil {
+		t.Fatal(err)
+	}
+	if err := DefaultRegistry.WriteHelp(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("expected the help output to be stable")
+	}
+	assertGolden(t, "help.golden", first.Bytes())
+}
+
+func TestCommandHelpSnapshot(t *testing.T) {
+	for _, name := range []string{"catch", "quit"} {
+		var out bytes.Buffer
+		if err := DefaultRegistry.WriteCommandHelp(&out, name); err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, "help_"+name+".golden", out.Bytes())
+	}
+}
+
+func TestHelpHidesCommands(t *testing.T) {
+	registry := NewRegistry()
+	noop := func(_ context.Context, _ *Config, _ ...string) (Result, error) { return nil, nil }
+	registry.Register(Command{Name: "visible", Description: "Shown", Callback: noop})
+	registry.Register(Command{Name: "secret", Description: "Not shown", Hidden: true, Callback: noop})
+	registry.Register(Command{Name: "shiny", Description: "New", Category: "Extras", Experimental: true, Callback: noop})
+
+	var out bytes.Buffer
+	if err := registry.WriteHelp(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Welcome to the Pokedex!\nUsage:\n\nGeneral:\n  visible  Shown\n\nExtras:\n  shiny    New (experimental)\n\nUse `help <command>` for details about a command.\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
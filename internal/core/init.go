@@ -1,48 +1,174 @@
 package core
 
-var supportedCommands = map[string]cliCommand{}
-
 func init() {
-	supportedCommands = map[string]cliCommand{
-		"exit": {
-			name:        "exit",
-			description: "Exit the Pokedex",
-			callback:    commandExit,
-		},
-		"help": {
-			name:        "help",
-			description: "Displays a help message",
-			callback:    displayHelp,
-		},
-		"map": {
-			name:        "map",
-			description: "Displays the next list of locations of the Pokemon World!",
-			callback:    mapNextPage,
-		},
-		"mapb": {
-			name:        "map",
-			description: "Displays the previous list of locations of the Pokemon World!",
-			callback:    mapPreviousPage,
-		},
-		"explore": {
-			name:        "explore",
-			description: "Display the list of pokemon species in each area. It can receive multiple areas as arguments.",
-			callback:    exploreAreas,
-		},
-		"catch": {
-			name:        "catch",
-			description: "Attempt to catch a pokemon species with your imaginary pokeball. Don't cry when you fail.",
-			callback:    catchPokemon,
-		},
-		"inspect": {
-			name:        "inspect",
-			description: "Inspect captured pokemon or pokemons in your Pokedex.",
-			callback:    inspect,
-		},
-		"pokedex": {
-			name:        "pokedex",
-			description: "Get the list of pokemons you have in your Pokedex!",
-			callback:    pokedex,
+	builtinCommands := []Command{
+		{
+			Name:        "exit",
+			Description: "Exit the Pokedex",
+			MaxArgs:     0,
+			Aliases:     []string{"quit"},
+			Examples:    []string{"exit"},
+			Category:    CategoryGeneral,
+			Callback:    commandExit,
+		},
+		{
+			Name:        "help",
+			Usage:       "[command]",
+			Description: "Displays a help message",
+			Arguments: []Argument{
+				{Name: "[command]", Description: "Show the details of a single command instead"},
+			},
+			Examples: []string{"help", "help catch"},
+			MaxArgs:  1,
+			Aliases:  []string{"?"},
+			Category: CategoryGeneral,
+			Callback: displayHelp,
+			Complete: completeCommandNames,
+		},
+		{
+			Name:        "map",
+			Usage:       "[first|last|page <n>]",
+			Description: "Displays the next list of locations of the Pokemon World!",
+			MaxArgs:     2,
+			Arguments: []Argument{
+				{Name: "first", Description: "Jump to the first page"},
+				{Name: "last", Description: "Jump to the last page"},
+				{Name: "page <n>", Description: "Jump to page <n>, counting from 1"},
+			},
+			Examples: []string{"map", "map first", "map page 3"},
+			Category: CategoryExploration,
+			Callback: mapNextPage,
+			Complete: completeMapArgs,
+		},
+		{
+			Name:        "mapb",
+			Description: "Displays the previous list of locations of the Pokemon World!",
+			MaxArgs:     0,
+			Examples:    []string{"mapb"},
+			Category:    CategoryExploration,
+			Callback:    mapPreviousPage,
+		},
+		{
+			Name:        "explore",
+			Usage:       "<area> [area...]",
+			Description: "Display the list of pokemon species in each area. It can receive multiple areas as arguments.",
+			MinArgs:     1,
+			MaxArgs:     Unlimited,
+			Arguments: []Argument{
+				{Name: "<area>", Description: "Name of a location area as listed by `map`"},
+			},
+			Examples: []string{"explore canalave-city-area", "explore eterna-city-area mt-coronet-1f-route-207"},
+			Category: CategoryExploration,
+			Callback: exploreAreas,
+			Complete: completeLocationAreas,
+		},
+		{
+			Name:        "catch",
+			Usage:       "<pokemon>",
+			Description: "Attempt to catch a pokemon species with your imaginary pokeball. Don't cry when you fail.",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Arguments: []Argument{
+				{Name: "<pokemon>", Description: "Name or national dex number of the pokemon species"},
+			},
+			Examples: []string{"catch pikachu", "catch 25"},
+			Category: CategoryPokedex,
+			Callback: catchPokemon,
+			Complete: completePokemonNames,
+		},
+		{
+			Name:        "inspect",
+			Usage:       "[id|species|nickname...]",
+			Description: "Inspect captured pokemons by ID, species or nickname. Inspects everything without arguments.",
+			MaxArgs:     Unlimited,
+			Arguments: []Argument{
+				{Name: "[id|species|nickname...]", Description: "Pokedex ID, species name or nickname of a captured pokemon"},
+			},
+			Examples: []string{"inspect", "inspect 3", "inspect pikachu sparky"},
+			Category: CategoryPokedex,
+			Callback: inspect,
+			Complete: completeCapturedPokemons,
 		},
+		{
+			Name:        "pokedex",
+			Description: "Get the list of pokemons you have in your Pokedex!",
+			MaxArgs:     0,
+			Aliases:     []string{"dex"},
+			Examples:    []string{"pokedex"},
+			Category:    CategoryPokedex,
+			Callback:    pokedex,
+		},
+		{
+			Name:        "nickname",
+			Usage:       "<id> <nickname>",
+			Description: "Give a captured pokemon a nickname. Takes the pokemon ID from `pokedex` and the nickname.",
+			MinArgs:     2,
+			MaxArgs:     2,
+			Arguments: []Argument{
+				{Name: "<id>", Description: "Pokedex ID of the captured pokemon"},
+				{Name: "<nickname>", Description: "The new nickname"},
+			},
+			Examples: []string{"nickname 3 sparky"},
+			Category: CategoryPokedex,
+			Callback: nickname,
+		},
+		{
+			Name:        "save",
+			Description: "Save your Pokedex to its save file.",
+			MaxArgs:     0,
+			Examples:    []string{"save"},
+			Category:    CategoryPokedex,
+			Callback:    savePokedex,
+		},
+		{
+			Name:        "load",
+			Usage:       "<path>",
+			Description: "Load a Pokedex from another save file. Catches are saved there from now on.",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Arguments: []Argument{
+				{Name: "<path>", Description: "Save file to switch to. It is created on the next save if missing."},
+			},
+			Examples: []string{"load ~/pokedex-tester.json"},
+			Category: CategoryPokedex,
+			KeepCase: true,
+			Callback: loadPokedex,
+		},
+		{
+			Name:        "reset",
+			Description: "Release every pokemon and start with an empty Pokedex.",
+			MaxArgs:     0,
+			Examples:    []string{"reset"},
+			Category:    CategoryPokedex,
+			Callback:    resetPokedex,
+		},
+		{
+			Name:        "cache",
+			Usage:       "<subcommand> [args...]",
+			Description: "Look into, clean up and carry around the cache of API responses.",
+			MinArgs:     1,
+			MaxArgs:     Unlimited,
+			Arguments: []Argument{
+				{Name: "stats", Description: "Entries, hits, misses, evictions and size"},
+				{Name: "list", Description: "Every cached URL"},
+				{Name: "clear", Description: "Remove everything, on disk included"},
+				{Name: "evict <key>", Description: "Remove one URL. The base URL may be left out, e.g. pokemon/pikachu"},
+				{Name: "export <file>", Description: "Pack every cached response into a .tar.gz bundle"},
+				{Name: "import <file>", Description: "Load a bundle. Entries keep their age and are served with --offline however old they are"},
+				{Name: "warm locations", Description: "Fetch every page `map` can show"},
+				{Name: "warm areas", Description: "Same, plus every location area for `explore`"},
+				{Name: "warm pokemon <name>...", Description: "Fetch these pokemons for `catch` and `inspect`"},
+			},
+			Examples: []string{"cache stats", "cache evict pokemon/pikachu", "cache warm areas", "cache export bundle.tar.gz"},
+			Category: CategoryCache,
+			KeepCase: true,
+			Callback: cacheCommand,
+			Complete: completeCacheArgs,
+		},
+	}
+	for _, cmd := range builtinCommands {
+		if err := Register(cmd); err != nil {
+			panic(err)
+		}
 	}
 }
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// What every command hands back instead of printing. The renderer turns it into
+// the format picked with --output.
+type Result interface {
+	// The human readable output, what the REPL has always printed.
+	WriteText(w io.Writer) error
+	// Column names and rows for `--output table`.
+	Table() ([]string, [][]string)
+}
+
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+	FormatTable Format = "table"
+)
+
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatTable}
+
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == strings.ToLower(s) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("error, unknown output format %q, expected one of text, json, yaml or table", s)
+}
+
+func Render(w io.Writer, format Format, result Result) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(result)
+	case FormatYAML:
+		return renderYAML(w, result)
+	case FormatTable:
+		return renderTable(w, result)
+	default:
+		return result.WriteText(w)
+	}
+}
+
+// Goes through JSON first so YAML output uses the same field names and order as
+// `--output json`.
+func renderYAML(w io.Writer, result Result) error {
+	byteData, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(byteData, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// JSON parses as flow style YAML with quoted strings. Reset that so the output
+// looks like YAML someone would write.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func renderTable(w io.Writer, result Result) error {
+	header, rows := result.Table()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+func TestRender(t *testing.T) {
+	result := LocationPageResult{
+		Count:     4,
+		Page:      1,
+		Pages:     2,
+		PageSize:  2,
+		Locations: []string{"canalave-city-area", "eterna-city-area"},
+	}
+	cases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format:   FormatText,
+			expected: "canalave-city-area\neterna-city-area\n",
+		},
+		{
+			format: FormatJSON,
+			expected: `{
+  "count": 4,
+  "page": 1,
+  "pages": 2,
+  "page_size": 2,
+  "locations": [
+    "canalave-city-area",
+    "eterna-city-area"
+  ]
+}
+`,
+		},
+		{
+			format: FormatYAML,
+			expected: `count: 4
+page: 1
+pages: 2
+page_size: 2
+locations:
+  - canalave-city-area
+  - eterna-city-area
+`,
+		},
+		{
+			format:   FormatTable,
+			expected: "LOCATION\ncanalave-city-area\neterna-city-area\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Render(&out, c.format, result); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != c.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if format, err := ParseFormat("JSON"); err != nil || format != FormatJSON {
+		t.Errorf("expected json, got %q and %v", format, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestCacheStatsText(t *testing.T) {
+	cases := []struct {
+		name     string
+		stats    pokecache.Stats
+		expected string
+	}{
+		{
+			name:     "uncompressed",
+			stats:    pokecache.Stats{Compression: pokecache.CompressionNone, UncompressedBytes: 2048, CompressedBytes: 2048},
+			expected: "Compression: none\n",
+		},
+		{
+			name:     "gzip",
+			stats:    pokecache.Stats{Compression: pokecache.CompressionGzip, UncompressedBytes: 4096, CompressedBytes: 1024},
+			expected: "Compression: gzip, 4.0 KiB down to 1.0 KiB (4.0x)\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := (CacheStatsResult{Stats: c.stats}).WriteText(&out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(out.String(), "\n"+c.expected) {
+				t.Errorf("expected the output to end with %q, got %q", c.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+)
+
+// Same as the API's own default.
+const DefaultPageSize = 20
+
+// Keeps track of where `map` and `mapb` are in the list of location areas. Pages are
+// fetched by offset and limit, so the URL (and with it the cache key) of a page is
+// always the same no matter how we got there.
+type Paginator struct {
+	PageSize int
+	page     int // NOTE: 0-based index of the page shown last, -1 before the first one
+	count    int // NOTE: Number of location areas, -1 until a page was fetched
+}
+
+func NewPaginator(pageSize int) *Paginator {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return &Paginator{PageSize: pageSize, page: -1, count: -1}
+}
+
+// 1-based number of the page shown last, 0 before the first one.
+func (p *Paginator) Page() int {
+	return p.page + 1
+}
+
+// Number of pages, 0 while it is not known yet.
+func (p *Paginator) Pages() int {
+	if p.count < 0 {
+		return 0
+	}
+	return max((p.count+p.PageSize-1)/p.PageSize, 1)
+}
+
+// The page after the current one. Before the first `map` that is the first page.
+func (p *Paginator) next() (int, error) {
+	if p.count >= 0 && p.page+1 >= p.Pages() {
+		return 0, fmt.Errorf("error, you are on the last page")
+	}
+	return p.page + 1, nil
+}
+
+func (p *Paginator) previous() (int, error) {
+	if p.page <= 0 {
+		return 0, fmt.Errorf("error, you are on the first page")
+	}
+	return p.page - 1, nil
+}
+
+// Checks a 1-based page number from the user and turns it into an index.
+func (p *Paginator) index(page int) (int, error) {
+	if page < 1 {
+		return 0, invalidArgument("error, pages start at 1")
+	}
+	if p.count >= 0 && page > p.Pages() {
+		return 0, invalidArgument("error, there are only %d pages", p.Pages())
+	}
+	return page - 1, nil
+}
+
+// Fetches the page at "index" and moves there if that worked.
+func (p *Paginator) fetch(ctx context.Context, client *pokeapi.Client, index int) (pokeapi.LocationAreas, error) {
+	locationData, err := client.GetLocationAreas(ctx, index*p.PageSize, p.PageSize)
+	if err != nil {
+		return locationData, err
+	}
+	p.count = locationData.Count
+	if len(locationData.Results) == 0 {
+		return locationData, fmt.Errorf("error, %w: there are no location areas on page %d", ErrEmptyResult, index+1)
+	}
+	p.page = index
+	return locationData, nil
+}
+
+// The number of pages is only known after a fetch. `map last` before anything was
+// shown fetches the first page to learn it.
+func (p *Paginator) last(ctx context.Context, client *pokeapi.Client) (int, error) {
+	if p.count < 0 {
+		locationData, err := client.GetLocationAreas(ctx, 0, p.PageSize)
+		if err != nil {
+			return 0, err
+		}
+		p.count = locationData.Count
+	}
+	return p.Pages() - 1, nil
+}
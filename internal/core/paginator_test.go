@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+func TestPaginator(t *testing.T) {
+	server := newTestServer(t, map[string][]string{
+		"area-a": nil, "area-b": nil, "area-c": nil, "area-d": nil, "area-e": nil,
+	})
+	steps := []struct {
+		command  string
+		args     []string
+		expected string // NOTE: The areas shown, empty when the command should fail
+	}{
+		{command: "mapb", expected: ""},
+		{command: "map", expected: "area-a,area-b"},
+		{command: "map", expected: "area-c,area-d"},
+		{command: "mapb", expected: "area-a,area-b"},
+		{command: "mapb", expected: ""},
+		{command: "map", args: []string{"last"}, expected: "area-e"},
+		{command: "map", expected: ""},
+		{command: "map", args: []string{"page", "2"}, expected: "area-c,area-d"},
+		{command: "map", args: []string{"page", "4"}, expected: ""},
+		{command: "map", args: []string{"page", "two"}, expected: ""},
+		{command: "map", args: []string{"first"}, expected: "area-a,area-b"},
+		{command: "map", args: []string{"sideways"}, expected: ""},
+	}
+
+	// NOTE: The second run is served from the cache and has to behave the same.
+	cache := pokecache.NewPokeCache(time.Minute, pokecache.WithoutReaping())
+	for run := 1; run <= 2; run++ {
+		config, out := newTestConfig(t, server)
+		config.Client = pokeapi.NewClient(server.URL, server.Client(), cache)
+		config.Pages = NewPaginator(2)
+		for i, step := range steps {
+			out.Reset()
+			err := RunSupportedCommand(context.Background(), config, step.command, step.args...)
+			actual := strings.Join(strings.Fields(out.String()), ",")
+			if step.expected == "" && err == nil {
+				t.Errorf("run %d, step %d (%s %v): expected an error, got %q", run, i, step.command, step.args, actual)
+			}
+			if step.expected != "" && (err != nil || actual != step.expected) {
+				t.Errorf("run %d, step %d (%s %v): expected %q, got %q and %v", run, i, step.command, step.args, step.expected, actual, err)
+			}
+		}
+	}
+}
+
+func TestBadArgumentsAreInvalidArgs(t *testing.T) {
+	server := newTestServer(t, map[string][]string{"area-a": nil, "area-b": nil, "area-c": nil})
+	config, _ := newTestConfig(t, server)
+	config.Pages = NewPaginator(2)
+	testCases := [][]string{
+		{"map", "page", "two"},
+		{"map", "page", "0"},
+		{"nickname", "abc", "sparky"},
+	}
+	for _, args := range testCases {
+		if err := RunSupportedCommand(context.Background(), config, args[0], args[1:]...); !errors.Is(err, ErrInvalidArgs) {
+			t.Errorf("%v: expected an invalid arguments error, got: %v", args, err)
+		}
+	}
+}
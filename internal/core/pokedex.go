@@ -0,0 +1,206 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bump this whenever the layout of saveFile changes and teach LoadPokedex how to
+// read the older versions.
+const saveFileVersion = 2
+
+// Version 1 stored one entry per species keyed by whatever the user typed in `catch`.
+type saveFileV1 struct {
+	Version  int                               `json:"version"`
+	Pokemons map[string]pokeapi.PokemonDetails `json:"pokemons"`
+}
+
+type saveFile struct {
+	Version  int                               `json:"version"`
+	NextID   int                               `json:"next_id"`
+	Species  map[string]pokeapi.PokemonDetails `json:"species"`
+	Pokemons []CaughtPokemon                   `json:"pokemons"`
+}
+
+// One captured pokemon. Catching the same species twice gives two of these.
+type CaughtPokemon struct {
+	ID       int       `json:"id"`
+	Species  string    `json:"species"`
+	Nickname string    `json:"nickname,omitempty"`
+	CaughtAt time.Time `json:"caught_at"`
+	Location string    `json:"location,omitempty"` // NOTE: Empty if nothing was explored before the catch
+}
+
+func (c CaughtPokemon) DisplayName() string {
+	if c.Nickname != "" {
+		return fmt.Sprintf("%s (%s)", c.Nickname, c.Species)
+	}
+	return c.Species
+}
+
+// The captured pokemons and the file they are saved to. Species details are kept
+// once per species no matter how many of them were caught.
+type Pokedex struct {
+	Path     string
+	NextID   int
+	Species  map[string]pokeapi.PokemonDetails
+	Pokemons []CaughtPokemon
+}
+
+func NewPokedex(path string) *Pokedex {
+	return &Pokedex{
+		Path:     path,
+		NextID:   1,
+		Species:  map[string]pokeapi.PokemonDetails{},
+		Pokemons: []CaughtPokemon{},
+	}
+}
+
+func (p *Pokedex) Add(pokemon pokeapi.PokemonDetails, location string) CaughtPokemon {
+	caught := CaughtPokemon{
+		ID:       p.NextID,
+		Species:  pokemon.Name,
+		CaughtAt: time.Now(),
+		Location: location,
+	}
+	p.NextID++
+	p.Species[pokemon.Name] = pokemon
+	p.Pokemons = append(p.Pokemons, caught)
+	return caught
+}
+
+// "ref" is either an instance ID, a species name or a nickname.
+func (p *Pokedex) Lookup(ref string) []CaughtPokemon {
+	var found []CaughtPokemon
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	for _, caught := range p.Pokemons {
+		if err == nil && caught.ID == id {
+			return []CaughtPokemon{caught}
+		}
+		if caught.Species == ref || caught.Nickname == ref {
+			found = append(found, caught)
+		}
+	}
+	return found
+}
+
+func (p *Pokedex) SetNickname(id int, nickname string) (CaughtPokemon, error) {
+	for i := range p.Pokemons {
+		if p.Pokemons[i].ID == id {
+			p.Pokemons[i].Nickname = nickname
+			return p.Pokemons[i], nil
+		}
+	}
+	return CaughtPokemon{}, fmt.Errorf("error, there is no captured pokemon with ID %d", id)
+}
+
+func (p *Pokedex) Reset() {
+	p.NextID = 1
+	p.Species = map[string]pokeapi.PokemonDetails{}
+	p.Pokemons = []CaughtPokemon{}
+}
+
+// Reads the Pokedex saved at "path". A missing file is not an error, it just means
+// nothing was captured yet.
+func LoadPokedex(path string) (*Pokedex, error) {
+	pokedex := NewPokedex(path)
+	byteData, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return pokedex, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error, failed to read save file %s: %w", path, err)
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(byteData, &header); err != nil {
+		return nil, fmt.Errorf("error, save file %s is corrupted: %w", path, err)
+	}
+
+	switch header.Version {
+	case 1:
+		var save saveFileV1
+		if err := json.Unmarshal(byteData, &save); err != nil {
+			return nil, fmt.Errorf("error, save file %s is corrupted: %w", path, err)
+		}
+		// NOTE: Version 1 did not know when or where anything was caught. Keys are
+		// sorted so the migrated IDs are the same every time.
+		keys := make([]string, 0, len(save.Pokemons))
+		for k := range save.Pokemons {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			pokedex.Add(save.Pokemons[k], "")
+			pokedex.Pokemons[len(pokedex.Pokemons)-1].CaughtAt = time.Time{}
+		}
+	case saveFileVersion:
+		var save saveFile
+		if err := json.Unmarshal(byteData, &save); err != nil {
+			return nil, fmt.Errorf("error, save file %s is corrupted: %w", path, err)
+		}
+		if save.Species != nil {
+			pokedex.Species = save.Species
+		}
+		if save.Pokemons != nil {
+			pokedex.Pokemons = save.Pokemons
+		}
+		pokedex.NextID = max(save.NextID, 1)
+	default:
+		return nil, fmt.Errorf("error, save file %s has unsupported version %d", path, header.Version)
+	}
+	return pokedex, nil
+}
+
+func (p *Pokedex) Save() error {
+	if p.Path == "" {
+		return fmt.Errorf("error, no save file configured")
+	}
+	save := saveFile{
+		Version:  saveFileVersion,
+		NextID:   p.NextID,
+		Species:  p.Species,
+		Pokemons: p.Pokemons,
+	}
+	byteData, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error, failed to encode the Pokedex: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0o755); err != nil {
+		return fmt.Errorf("error, failed to create directory for save file %s: %w", p.Path, err)
+	}
+	// NOTE: Write next to the real file and rename so a crash never eats the save.
+	tmpPath := p.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, byteData, 0o644); err != nil {
+		return fmt.Errorf("error, failed to write save file %s: %w", p.Path, err)
+	}
+	if err := os.Rename(tmpPath, p.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error, failed to write save file %s: %w", p.Path, err)
+	}
+	return nil
+}
+
+// Default location of the save file. Honours $XDG_DATA_HOME.
+func DefaultSavePath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "pokedexcli", "pokedex.json"), nil
+}
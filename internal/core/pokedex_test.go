@@ -0,0 +1,80 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+)
+
+func TestPokedexSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "pokedex.json")
+	pokedex := NewPokedex(path)
+	pokedex.Add(pokeapi.PokemonDetails{ID: 25, Name: "pikachu"}, "viridian-forest-area")
+	pokedex.Add(pokeapi.PokemonDetails{ID: 25, Name: "pikachu"}, "")
+	if _, err := pokedex.SetNickname(2, "sparky"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pokedex.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadPokedex(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Pokemons) != 2 {
+		t.Fatalf("expected 2 captured pokemons, got %d", len(loaded.Pokemons))
+	}
+	if loaded.Species["pikachu"].ID != 25 {
+		t.Errorf("expected to find pikachu details in the loaded Pokedex")
+	}
+	if found := loaded.Lookup("sparky"); len(found) != 1 || found[0].ID != 2 {
+		t.Errorf("expected to find #2 by its nickname, got %v", found)
+	}
+	if found := loaded.Lookup("pikachu"); len(found) != 2 {
+		t.Errorf("expected to find both pikachus, got %v", found)
+	}
+	if caught := loaded.Add(pokeapi.PokemonDetails{ID: 1, Name: "bulbasaur"}, ""); caught.ID != 3 {
+		t.Errorf("expected the next ID to be 3, got %d", caught.ID)
+	}
+}
+
+func TestLoadPokedexVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	v1 := `{"version": 1, "pokemons": {"25": {"id": 25, "name": "pikachu"}, "bulbasaur": {"id": 1, "name": "bulbasaur"}}}`
+	if err := os.WriteFile(path, []byte(v1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPokedex(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Pokemons) != 2 {
+		t.Fatalf("expected 2 captured pokemons, got %d", len(loaded.Pokemons))
+	}
+	if found := loaded.Lookup("pikachu"); len(found) != 1 {
+		t.Errorf("expected pikachu to be migrated by species name, got %v", found)
+	}
+}
+
+func TestLoadPokedexMissingFile(t *testing.T) {
+	loaded, err := LoadPokedex(filepath.Join(t.TempDir(), "pokedex.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Pokemons) != 0 {
+		t.Errorf("expected an empty Pokedex")
+	}
+}
+
+func TestLoadPokedexUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	if err := os.WriteFile(path, []byte(`{"version": 999, "pokemons": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPokedex(path); err == nil {
+		t.Errorf("expected an error for an unsupported version")
+	}
+}
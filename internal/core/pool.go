@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// Used when Config.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// Runs "fetch" for every index below "n" with at most "concurrency" of them running
+// at once. Results and errors come back in index order, so output follows the order
+// of the arguments no matter which fetch finished first. Once "ctx" is done the
+// remaining indexes are not fetched and get its error.
+func fetchAll[T any](ctx context.Context, concurrency, n int, fetch func(ctx context.Context, i int) (T, error)) ([]T, []error) {
+	results := make([]T, n)
+	errs := make([]error, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = fetch(ctx, i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, errs
+}
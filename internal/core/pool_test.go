@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchAll(t *testing.T) {
+	var running, peak atomic.Int32
+	results, errs := fetchAll(context.Background(), 3, 10, func(_ context.Context, i int) (int, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		// NOTE: Later indexes finish first, the order has to come from the index.
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		if i%4 == 0 {
+			return 0, fmt.Errorf("error, %d failed", i)
+		}
+		return i * i, nil
+	})
+
+	if peak.Load() > 3 {
+		t.Errorf("expected at most 3 fetches at once, got %d", peak.Load())
+	}
+	for i := range 10 {
+		if i%4 == 0 {
+			if errs[i] == nil {
+				t.Errorf("%d: expected an error", i)
+			}
+			continue
+		}
+		if errs[i] != nil || results[i] != i*i {
+			t.Errorf("%d: expected %d, got %d and %v", i, i*i, results[i], errs[i])
+		}
+	}
+}
+
+func TestFetchAllCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	_, errs := fetchAll(ctx, 2, 5, func(_ context.Context, _ int) (int, error) {
+		calls.Add(1)
+		return 0, nil
+	})
+	if calls.Load() != 0 {
+		t.Errorf("expected no fetches after cancelling, got %d", calls.Load())
+	}
+	for i, err := range errs {
+		if err != context.Canceled {
+			t.Errorf("%d: expected context.Canceled, got %v", i, err)
+		}
+	}
+}
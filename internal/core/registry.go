@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Use as Command.MaxArgs for commands that take any number of arguments.
+const Unlimited = -1
+
+const (
+	CategoryGeneral     = "General"
+	CategoryExploration = "Exploration"
+	CategoryPokedex     = "Pokedex"
+	CategoryCache       = "Cache"
+)
+
+// Shown by `help <command>`.
+type Argument struct {
+	Name        string
+	Description string
+}
+
+type Command struct {
+	Name         string
+	Usage        string // NOTE: Arguments only, e.g. "<pokemon>". The name is added when displayed.
+	Description  string
+	Arguments    []Argument
+	Examples     []string
+	MinArgs      int
+	MaxArgs      int // NOTE: Unlimited for no upper bound
+	Aliases      []string
+	Category     string
+	Hidden       bool // NOTE: Hidden commands still run but are not listed in `help`
+	Experimental bool
+	KeepCase     bool // NOTE: Arguments are passed as typed, e.g. file paths. Otherwise they are lowercased.
+	Callback     func(ctx context.Context, config *Config, args ...string) (Result, error)
+	Complete     func(config *Config, args []string) []string // NOTE: Optional. Candidates for the next argument.
+}
+
+func (c Command) acceptsArgs(n int) bool {
+	if n < c.MinArgs {
+		return false
+	}
+	return c.MaxArgs == Unlimited || n <= c.MaxArgs
+}
+
+// Commands by name, plus their aliases. Safe to use from several goroutines.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+	aliases  map[string]string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: make(map[string]Command),
+		aliases:  make(map[string]string),
+	}
+}
+
+// The registry used by RunSupportedCommand. Other packages add their commands here.
+var DefaultRegistry = NewRegistry()
+
+func Register(cmd Command) error {
+	return DefaultRegistry.Register(cmd)
+}
+
+func (r *Registry) Register(cmd Command) error {
+	if cmd.Name == "" {
+		return fmt.Errorf("error, a command needs a name")
+	}
+	if cmd.Callback == nil {
+		return fmt.Errorf("error, command %s has no callback", cmd.Name)
+	}
+	if cmd.MinArgs < 0 || (cmd.MaxArgs != Unlimited && cmd.MaxArgs < cmd.MinArgs) {
+		return fmt.Errorf("error, command %s has an invalid argument range %d..%d", cmd.Name, cmd.MinArgs, cmd.MaxArgs)
+	}
+	if cmd.Category == "" {
+		cmd.Category = CategoryGeneral
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if r.taken(name) {
+			return fmt.Errorf("error, command name %s is already registered", name)
+		}
+	}
+	r.commands[cmd.Name] = cmd
+	for _, alias := range cmd.Aliases {
+		r.aliases[alias] = cmd.Name
+	}
+	return nil
+}
+
+func (r *Registry) taken(name string) bool {
+	_, isCommand := r.commands[name]
+	_, isAlias := r.aliases[name]
+	return isCommand || isAlias
+}
+
+// Finds a command by its name or one of its aliases.
+func (r *Registry) Lookup(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if target, ok := r.aliases[name]; ok {
+		name = target
+	}
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Every registered command, hidden ones included, sorted by name.
+func (r *Registry) Commands() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	commands := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// Checks the number of arguments against the command before calling it, so callbacks
+// do not have to.
+func (r *Registry) Run(ctx context.Context, config *Config, name string, args ...string) (Result, error) {
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return nil, commandNotFound(name)
+	}
+	if !cmd.acceptsArgs(len(args)) {
+		return nil, &UsageError{Command: cmd.Name, Usage: cmd.UsageLine()}
+	}
+	if !cmd.KeepCase {
+		lowered := make([]string, len(args))
+		for i, arg := range args {
+			lowered[i] = strings.ToLower(arg)
+		}
+		args = lowered
+	}
+	return cmd.Callback(ctx, config, args...)
+}
+
+func (c Command) UsageLine() string {
+	if c.Usage == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Usage
+}
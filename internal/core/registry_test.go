@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegistryArity(t *testing.T) {
+	registry := NewRegistry()
+	var called int
+	err := registry.Register(Command{
+		Name:     "greet",
+		Usage:    "<name> [name...]",
+		MinArgs:  1,
+		MaxArgs:  2,
+		Aliases:  []string{"hi"},
+		Callback: func(_ context.Context, _ *Config, _ ...string) (Result, error) { called++; return nil, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "greet", args: nil, wantErr: true},
+		{name: "greet", args: []string{"ash"}, wantErr: false},
+		{name: "hi", args: []string{"ash", "misty"}, wantErr: false},
+		{name: "hi", args: []string{"ash", "misty", "brock"}, wantErr: true},
+		{name: "wave", args: nil, wantErr: true},
+	}
+	for _, c := range cases {
+		_, err := registry.Run(context.Background(), nil, c.name, c.args...)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s %v: expected error: %v, got: %v", c.name, c.args, c.wantErr, err)
+		}
+	}
+	if called != 2 {
+		t.Errorf("expected the callback to be called 2 times, got %d", called)
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	registry := NewRegistry()
+	noop := func(_ context.Context, _ *Config, _ ...string) (Result, error) { return nil, nil }
+	if err := registry.Register(Command{Name: "map", Aliases: []string{"m"}, Callback: noop}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(Command{Name: "map", Callback: noop}); err == nil {
+		t.Errorf("expected an error for a duplicate name")
+	}
+	if err := registry.Register(Command{Name: "m", Callback: noop}); err == nil {
+		t.Errorf("expected an error for a name taken by an alias")
+	}
+}
+
+func TestRegistryComplete(t *testing.T) {
+	registry := NewRegistry()
+	noop := func(_ context.Context, _ *Config, _ ...string) (Result, error) { return nil, nil }
+	registry.Register(Command{Name: "catch", MinArgs: 1, MaxArgs: 1, Callback: noop,
+		Complete: func(_ *Config, _ []string) []string { return []string{"pichu", "pikachu", "bulbasaur"} }})
+	registry.Register(Command{Name: "cache", Callback: noop})
+	registry.Register(Command{Name: "secret", Hidden: true, Callback: noop})
+
+	cases := []struct {
+		words    []string
+		prefix   string
+		expected []string
+	}{
+		{words: nil, prefix: "ca", expected: []string{"cache", "catch"}},
+		{words: nil, prefix: "se", expected: nil},
+		{words: []string{"catch"}, prefix: "pi", expected: []string{"pichu", "pikachu"}},
+		{words: []string{"catch", "pikachu"}, prefix: "", expected: nil},
+		{words: []string{"cache"}, prefix: "", expected: nil},
+	}
+	for _, c := range cases {
+		actual := registry.Complete(nil, c.words, c.prefix)
+		if strings.Join(actual, ",") != strings.Join(c.expected, ",") {
+			t.Errorf("%v %q: expected %v, got %v", c.words, c.prefix, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+)
+
+type MessageResult struct {
+	Message string `json:"message"`
+}
+
+func (r MessageResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Message)
+	return err
+}
+
+func (r MessageResult) Table() ([]string, [][]string) {
+	return []string{"MESSAGE"}, [][]string{{r.Message}}
+}
+
+// `help` without arguments lists every command, `help <command>` only one.
+type HelpResult struct {
+	Commands []CommandInfo `json:"commands"`
+	registry *Registry
+	command  string
+}
+
+type CommandInfo struct {
+	Name         string     `json:"name"`
+	Usage        string     `json:"usage"`
+	Description  string     `json:"description"`
+	Category     string     `json:"category"`
+	Aliases      []string   `json:"aliases,omitempty"`
+	Arguments    []Argument `json:"arguments,omitempty"`
+	Examples     []string   `json:"examples,omitempty"`
+	Experimental bool       `json:"experimental,omitempty"`
+}
+
+func newCommandInfo(cmd Command) CommandInfo {
+	return CommandInfo{
+		Name:         cmd.Name,
+		Usage:        cmd.UsageLine(),
+		Description:  cmd.Description,
+		Category:     cmd.Category,
+		Aliases:      cmd.Aliases,
+		Arguments:    cmd.Arguments,
+		Examples:     cmd.Examples,
+		Experimental: cmd.Experimental,
+	}
+}
+
+func (r HelpResult) WriteText(w io.Writer) error {
+	if r.command != "" {
+		return r.registry.WriteCommandHelp(w, r.command)
+	}
+	return r.registry.WriteHelp(w)
+}
+
+func (r HelpResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, cmd := range r.Commands {
+		rows = append(rows, []string{cmd.Usage, cmd.Category, cmd.Description})
+	}
+	return []string{"COMMAND", "CATEGORY", "DESCRIPTION"}, rows
+}
+
+// One page of location areas. Page is 1-based, Count is the number of location areas
+// on all pages.
+type LocationPageResult struct {
+	Count     int      `json:"count"`
+	Page      int      `json:"page"`
+	Pages     int      `json:"pages"`
+	PageSize  int      `json:"page_size"`
+	Locations []string `json:"locations"`
+}
+
+func (r LocationPageResult) WriteText(w io.Writer) error {
+	for _, location := range r.Locations {
+		if _, err := fmt.Fprintln(w, location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r LocationPageResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, location := range r.Locations {
+		rows = append(rows, []string{location})
+	}
+	return []string{"LOCATION"}, rows
+}
+
+type ExploreResult struct {
+	Areas []AreaEncounters `json:"areas"`
+}
+
+type AreaEncounters struct {
+	Area     string   `json:"area"`
+	Pokemons []string `json:"pokemons"`
+}
+
+func (r ExploreResult) WriteText(w io.Writer) error {
+	for _, area := range r.Areas {
+		fmt.Fprintf(w, "Exploring %s...\n", area.Area)
+		for _, pokemon := range area.Pokemons {
+			if _, err := fmt.Fprintln(w, pokemon); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r ExploreResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, area := range r.Areas {
+		for _, pokemon := range area.Pokemons {
+			rows = append(rows, []string{area.Area, pokemon})
+		}
+	}
+	return []string{"AREA", "POKEMON"}, rows
+}
+
+// Pokemon is only set when the pokemon was caught.
+type CatchResult struct {
+	Species string         `json:"species"`
+	Caught  bool           `json:"caught"`
+	Pokemon *CaughtPokemon `json:"pokemon,omitempty"`
+}
+
+func (r CatchResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Throwing a Pokeball at %s...\n", r.Species)
+	if r.Caught {
+		_, err := fmt.Fprintf(w, "You have caught %s! 🎉 It is #%d in your Pokedex.\n", r.Species, r.Pokemon.ID)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s escaped and ran away! 😩\n", r.Species)
+	return err
+}
+
+func (r CatchResult) Table() ([]string, [][]string) {
+	id := ""
+	if r.Pokemon != nil {
+		id = strconv.Itoa(r.Pokemon.ID)
+	}
+	return []string{"SPECIES", "CAUGHT", "ID"}, [][]string{{r.Species, strconv.FormatBool(r.Caught), id}}
+}
+
+type PokedexResult struct {
+	Pokemons []CaughtPokemon `json:"pokemons"`
+}
+
+func (r PokedexResult) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "Your Pokedex:")
+	for _, caught := range r.Pokemons {
+		if _, err := fmt.Fprintf(w, "  - #%d %s\n", caught.ID, caught.DisplayName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r PokedexResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, caught := range r.Pokemons {
+		rows = append(rows, caughtRow(caught))
+	}
+	return caughtHeader, rows
+}
+
+var caughtHeader = []string{"ID", "SPECIES", "NICKNAME", "CAUGHT AT", "LOCATION"}
+
+func caughtRow(caught CaughtPokemon) []string {
+	caughtAt := ""
+	if !caught.CaughtAt.IsZero() {
+		caughtAt = caught.CaughtAt.Format("2006-01-02 15:04")
+	}
+	return []string{strconv.Itoa(caught.ID), caught.Species, caught.Nickname, caughtAt, caught.Location}
+}
+
+// The parts of pokeapi.PokemonDetails that `inspect` shows.
+type PokemonSummary struct {
+	Name           string        `json:"name"`
+	Height         int           `json:"height"`
+	Weight         int           `json:"weight"`
+	BaseExperience int           `json:"base_experience"`
+	Stats          []PokemonStat `json:"stats"`
+	Types          []string      `json:"types"`
+}
+
+type PokemonStat struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func newPokemonSummary(pokemon pokeapi.PokemonDetails) PokemonSummary {
+	summary := PokemonSummary{
+		Name:           pokemon.Name,
+		Height:         pokemon.Height,
+		Weight:         pokemon.Weight,
+		BaseExperience: pokemon.BaseExperience,
+	}
+	for _, stat := range pokemon.Stats {
+		summary.Stats = append(summary.Stats, PokemonStat{Name: stat.Stat.Name, Value: stat.BaseStat})
+	}
+	for _, type_ := range pokemon.Types {
+		summary.Types = append(summary.Types, type_.Type.Name)
+	}
+	return summary
+}
+
+type InspectedPokemon struct {
+	CaughtPokemon
+	Details PokemonSummary `json:"details"`
+}
+
+// Refs that did not match a captured pokemon end up in NotFound with the reason.
+type InspectResult struct {
+	Pokemons []InspectedPokemon `json:"pokemons"`
+	NotFound []InspectMiss      `json:"not_found,omitempty"`
+}
+
+type InspectMiss struct {
+	Ref    string `json:"ref"`
+	Reason string `json:"reason"`
+}
+
+func (r InspectResult) WriteText(w io.Writer) error {
+	for _, miss := range r.NotFound {
+		fmt.Fprintln(w, miss.Reason)
+	}
+	for _, inspected := range r.Pokemons {
+		var stats []string
+		for _, stat := range inspected.Details.Stats {
+			stats = append(stats, fmt.Sprintf("  -%s: %d", stat.Name, stat.Value))
+		}
+		var types []string
+		for _, type_ := range inspected.Details.Types {
+			types = append(types, fmt.Sprintf("  - %s", type_))
+		}
+
+		details := fmt.Sprintf(`#%d %s
+Name: %s
+Height: %d
+Weight: %d
+Caught: %s
+Stats:
+%s
+Types:
+%s
+`, inspected.ID, inspected.DisplayName(), inspected.Details.Name, inspected.Details.Height, inspected.Details.Weight, caughtWhere(inspected.CaughtPokemon), strings.Join(stats, "\n"), strings.Join(types, "\n"))
+		if _, err := fmt.Fprintln(w, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r InspectResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, inspected := range r.Pokemons {
+		rows = append(rows, []string{
+			strconv.Itoa(inspected.ID),
+			inspected.DisplayName(),
+			strconv.Itoa(inspected.Details.Height),
+			strconv.Itoa(inspected.Details.Weight),
+			strings.Join(inspected.Details.Types, ","),
+		})
+	}
+	return []string{"ID", "NAME", "HEIGHT", "WEIGHT", "TYPES"}, rows
+}
+
+func caughtWhere(caught CaughtPokemon) string {
+	when := "some time ago"
+	if !caught.CaughtAt.IsZero() {
+		when = caught.CaughtAt.Format("2006-01-02 15:04")
+	}
+	if caught.Location == "" {
+		return when
+	}
+	return fmt.Sprintf("%s at %s", when, caught.Location)
+}
+
+type NicknameResult struct {
+	Pokemon CaughtPokemon `json:"pokemon"`
+}
+
+func (r NicknameResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "#%d is now known as %s.\n", r.Pokemon.ID, r.Pokemon.DisplayName())
+	return err
+}
+
+func (r NicknameResult) Table() ([]string, [][]string) {
+	return caughtHeader, [][]string{caughtRow(r.Pokemon)}
+}
+
+// Result of `save`, `load` and `reset`.
+type SaveFileResult struct {
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	Pokemons int    `json:"pokemons"`
+	New      bool   `json:"new,omitempty"` // NOTE: Loaded a save file that does not exist yet
+}
+
+func (r SaveFileResult) WriteText(w io.Writer) error {
+	var err error
+	switch r.Action {
+	case "load":
+		if r.New {
+			_, err = fmt.Fprintf(w, "There is no save file at %s yet. Starting an empty Pokedex that is saved there.\n", r.Path)
+			break
+		}
+		_, err = fmt.Fprintf(w, "Loaded %d pokemons from %s\n", r.Pokemons, r.Path)
+	case "reset":
+		_, err = fmt.Fprintln(w, "Your Pokedex is empty again.")
+	default:
+		_, err = fmt.Fprintf(w, "Saved your Pokedex to %s\n", r.Path)
+	}
+	return err
+}
+
+func (r SaveFileResult) Table() ([]string, [][]string) {
+	return []string{"ACTION", "PATH", "POKEMONS"}, [][]string{{r.Action, r.Path, strconv.Itoa(r.Pokemons)}}
+}
@@ -1,7 +1,34 @@
 package core
 
-import "strings"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
 
 func CleanInput(text string) []string {
 	return strings.Fields(strings.ToLower(text))
 }
+
+// Like CleanInput but only the command name is lowercased. Arguments are lowercased
+// by Registry.Run unless the command wants them as typed, e.g. for file paths.
+func SplitInput(text string) []string {
+	words := strings.Fields(text)
+	if len(words) > 0 {
+		words[0] = strings.ToLower(words[0])
+	}
+	return words
+}
+
+// Expands a leading "~" to the home directory, like a shell would.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error, could not expand %s: %w", path, err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,263 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+const DefaultBaseURL = "https://pokeapi.co/api/v2"
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	cache      pokecache.Cache
+	offline    bool
+	timeout    time.Duration
+	flights    flightGroup
+	logger     *slog.Logger
+	refreshMu  sync.Mutex
+	refreshing map[string]struct{} // NOTE: URLs with a background refresh running
+	refreshes  sync.WaitGroup
+}
+
+// A nil cache disables caching. A nil httpClient falls back to http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client, cache pokecache.Cache) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		cache:      cache,
+	}
+}
+
+func DefaultClient() *Client {
+	return NewClient(DefaultBaseURL, &http.Client{}, pokecache.DefaultPokeCache())
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// The cache responses are kept in, nil if caching is disabled.
+func (c *Client) Cache() pokecache.Cache {
+	return c.cache
+}
+
+// In offline mode the client never touches the network and only serves what is
+// already in the cache, expired or not. Misses fail with ErrNotCached.
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+	// NOTE: So the cache keeps expired entries instead of dropping them on read.
+	if offlineCache, ok := c.cache.(interface{ SetOffline(bool) }); ok {
+		offlineCache.SetOffline(offline)
+	}
+}
+
+func (c *Client) Offline() bool {
+	return c.offline
+}
+
+// Upper bound for a single request, on top of whatever deadline the caller's context
+// has. Zero means no per-request timeout.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Where the client logs to. Defaults to slog.Default.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+func (c *Client) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+// Fetches "fullURL" and decodes it into a T. Concurrent calls for the same URL share
+// one fetch and one decoded value, so callers must not modify what they get back.
+func fetchJSON[T any](ctx context.Context, c *Client, fullURL string) (T, error) {
+	val, err := c.flights.do(ctx, fullURL, func(ctx context.Context) (any, error) {
+		var target T
+		err := c.getJSON(ctx, fullURL, &target)
+		return target, err
+	})
+	target, ok := val.(T)
+	if !ok && err == nil {
+		// NOTE: Someone fetched the same URL into another type. Decode our own copy.
+		err = c.getJSON(ctx, fullURL, &target)
+	}
+	return target, err
+}
+
+// Fetches "fullURL" and decodes the JSON body into "target". The raw body is what
+// gets cached so every caller decodes the same bytes the API gave us.
+func (c *Client) getJSON(ctx context.Context, fullURL string, target any) error {
+	if c.cache != nil {
+		if cachedData, ok := c.cache.Get(fullURL); ok {
+			if err := json.Unmarshal(cachedData, target); err != nil {
+				return &DecodeError{URL: fullURL, Cached: true, Err: err}
+			}
+			return nil
+		}
+	}
+	stale := c.staleEntry(fullURL)
+	// NOTE: Stale-while-revalidate answers right away and the next call gets the new
+	// data. Offline an expired entry beats no entry, whatever its age.
+	if stale != nil && (stale.Stale || c.offline) {
+		if err := json.Unmarshal(stale.Val, target); err == nil {
+			c.log().Debug("Serving stale cache entry", "url", fullURL, "age", time.Since(stale.CreatedAt).Round(time.Second))
+			if !c.offline {
+				c.refreshInBackground(fullURL, stale)
+			}
+			return nil
+		}
+	}
+	if c.offline {
+		return fmt.Errorf("%s is not available offline: %w", fullURL, ErrNotCached)
+	}
+	err := c.fetchRemote(ctx, fullURL, stale, target)
+	// NOTE: Out of date beats nothing when the API cannot be reached, unless the caller
+	// gave up.
+	if stale != nil && errors.Is(err, ErrNetwork) && ctx.Err() == nil {
+		if json.Unmarshal(stale.Val, target) == nil {
+			c.log().Warn("Serving expired cache entry, the API cannot be reached", "url", fullURL, "age", time.Since(stale.CreatedAt).Round(time.Second), "error", err)
+			return nil
+		}
+	}
+	return err
+}
+
+// The expired entry for "fullURL", if the cache kept one.
+func (c *Client) staleEntry(fullURL string) *pokecache.Entry {
+	revalidating, ok := c.cache.(pokecache.RevalidatingCache)
+	if !ok {
+		return nil
+	}
+	entry, fresh, ok := revalidating.Lookup(fullURL)
+	if !ok || fresh {
+		return nil
+	}
+	return &entry
+}
+
+// Requests "fullURL" from the API, decodes it into "target" and caches it. An expired
+// entry with an ETag or Last-Modified makes it a conditional request, and a 304 Not
+// Modified refreshes that entry instead of downloading it again.
+func (c *Client) fetchRemote(ctx context.Context, fullURL string, stale *pokecache.Entry, target any) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid request for %s: %w", fullURL, err)
+	}
+	revalidate := stale != nil && (stale.ETag != "" || stale.LastModified != "")
+	if revalidate {
+		if stale.ETag != "" {
+			req.Header.Set("If-None-Match", stale.ETag)
+		}
+		if stale.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stale.LastModified)
+		}
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &NetworkError{URL: fullURL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	revalidating, _ := c.cache.(pokecache.RevalidatingCache)
+	if resp.StatusCode == http.StatusNotModified && revalidate {
+		if err := json.Unmarshal(stale.Val, target); err != nil {
+			return &DecodeError{URL: fullURL, Cached: true, Err: err}
+		}
+		revalidating.Refresh(fullURL, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+		return nil
+	}
+	if resp.StatusCode > 299 {
+		statusErr := &StatusError{URL: fullURL, StatusCode: resp.StatusCode, Status: resp.Status}
+		statusErr.RetryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return statusErr
+	}
+
+	byteData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &NetworkError{URL: fullURL, Err: err}
+	}
+
+	if err := json.Unmarshal(byteData, target); err != nil {
+		return &DecodeError{URL: fullURL, Err: err}
+	}
+
+	switch {
+	case revalidating != nil:
+		revalidating.AddEntry(fullURL, pokecache.Entry{
+			Val:          byteData,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		})
+	case c.cache != nil:
+		c.cache.Add(fullURL, byteData)
+	}
+	return nil
+}
+
+// Brings a stale entry up to date without anyone waiting for it. Only one refresh per
+// URL runs at a time, and a failed one leaves the stale entry as it is.
+func (c *Client) refreshInBackground(fullURL string, stale *pokecache.Entry) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if _, ok := c.refreshing[fullURL]; ok {
+		return
+	}
+	if c.refreshing == nil {
+		c.refreshing = make(map[string]struct{})
+	}
+	c.refreshing[fullURL] = struct{}{}
+	c.refreshes.Add(1)
+	go func() {
+		defer c.refreshes.Done()
+		var body json.RawMessage
+		if err := c.fetchRemote(context.Background(), fullURL, stale, &body); err != nil {
+			c.log().Debug("Failed to refresh stale cache entry", "url", fullURL, "error", err)
+		}
+		c.refreshMu.Lock()
+		delete(c.refreshing, fullURL)
+		c.refreshMu.Unlock()
+	}()
+}
+
+// Waits for background refreshes of stale entries to finish, or for "ctx" to be done.
+// The refreshes keep going in the second case.
+func (c *Client) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
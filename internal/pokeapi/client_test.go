@@ -0,0 +1,309 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+func TestGetPokemonUsesCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/pokemon/pikachu" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu", "base_experience": 112}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), pokecache.NewPokeCache(5*time.Second, pokecache.WithoutReaping()))
+	for i := 0; i < 2; i++ {
+		pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pokemon.ID != 25 || pokemon.Name != "pikachu" {
+			t.Errorf("unexpected pokemon: %d %s", pokemon.ID, pokemon.Name)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request to the server, got %d", hits.Load())
+	}
+}
+
+func TestGetLocationAreaNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	_, err := client.GetLocationArea(context.Background(), "nowhere")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not found error, got: %v", err)
+	}
+}
+
+func TestGetPokemonDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html>maintenance</html>`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || !errors.Is(err, ErrDecode) {
+		t.Errorf("expected a decode error, got: %v", err)
+	}
+}
+
+func TestOfflineServesOnlyFromCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), pokecache.NewPokeCache(5*time.Second, pokecache.WithoutReaping()))
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.SetOffline(true)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Errorf("expected a cached pokemon offline, got: %v", err)
+	}
+	if _, err := client.GetPokemon(context.Background(), "bulbasaur"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached, got: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request to the server, got %d", hits.Load())
+	}
+}
+
+func TestOfflineServesExpiredEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	const ttl = 50 * time.Millisecond
+	cache, err := pokecache.NewPersistentPokeCache(t.TempDir(), ttl, 0, pokecache.WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(server.URL, server.Client(), cache)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close()
+
+	time.Sleep(ttl + 10*time.Millisecond)
+
+	client.SetOffline(true)
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected the expired pokemon offline, got %q and %v", pokemon.Name, err)
+	}
+}
+
+func TestExpiredEntrySurvivesFailedFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	const ttl = 50 * time.Millisecond
+	dir := t.TempDir()
+	cache, err := pokecache.NewPersistentPokeCache(dir, ttl, 0, pokecache.WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(server.URL, server.Client(), cache)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close()
+
+	time.Sleep(ttl + 10*time.Millisecond)
+
+	// NOTE: Online with the API gone, e.g. --offline was forgotten.
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected the expired pokemon when the API cannot be reached, got %q and %v", pokemon.Name, err)
+	}
+
+	reopened, err := pokecache.NewPersistentPokeCache(dir, ttl, 0, pokecache.WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	offline := NewClient(server.URL, server.Client(), reopened)
+	offline.SetOffline(true)
+	if _, err := offline.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Errorf("expected the expired pokemon to still be on disk, got %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	client.SetTimeout(50 * time.Millisecond)
+	_, err := client.GetPokemon(context.Background(), "slowpoke")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got: %v", err)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 79, "name": "slowpoke"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GetPokemon(ctx, "slowpoke")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled error, got: %v", err)
+	}
+}
+
+// Remembers what the client asks of it and never returns anything.
+type recordingCache struct {
+	pokecache.NopCache
+	mu    sync.Mutex
+	added []string
+}
+
+func (c *recordingCache) Add(key string, _ []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.added = append(c.added, key)
+}
+
+func TestClientWritesToInjectedCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	cache := &recordingCache{}
+	client := NewClient(server.URL, server.Client(), cache)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cache.added) != 1 || cache.added[0] != server.URL+"/pokemon/pikachu" {
+		t.Errorf("expected the response to be cached under its URL, got %v", cache.added)
+	}
+	if names := client.CachedPokemonNames(); len(names) != 0 {
+		t.Errorf("expected no names from a cache that returns nothing, got %v", names)
+	}
+}
+
+func TestRevalidatesExpiredEntries(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"pikachu-v1"`)
+		if r.Header.Get("If-None-Match") == `"pikachu-v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	const ttl = 50 * time.Millisecond
+	cache := pokecache.NewPokeCache(ttl, pokecache.WithoutReaping())
+	client := NewClient(server.URL, server.Client(), cache)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(ttl + 10*time.Millisecond)
+
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.Name != "pikachu" {
+		t.Errorf("expected the cached pokemon after a 304, got %q", pokemon.Name)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("expected 2 requests with 1 revalidation, got %d and %d", requests.Load(), notModified.Load())
+	}
+	// NOTE: The 304 made the entry fresh again, no request needed.
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected the refreshed entry to be used, got %d requests", requests.Load())
+	}
+	if stats := cache.Stats(); stats.Revalidations != 1 {
+		t.Errorf("expected 1 revalidation, got %+v", stats)
+	}
+}
+
+func TestServesStaleWhileRefreshing(t *testing.T) {
+	var version atomic.Int32
+	version.Store(1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := version.Load()
+		if v > 1 {
+			<-release // NOTE: Hold the refresh so the stale answer cannot wait for it
+		}
+		fmt.Fprintf(w, `{"id": %d, "name": "pikachu"}`, v)
+	}))
+	defer server.Close()
+
+	const ttl = 50 * time.Millisecond
+	cache := pokecache.NewPokeCache(ttl, pokecache.WithoutReaping(), pokecache.WithMaxStale(time.Minute))
+	client := NewClient(server.URL, server.Client(), cache)
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(ttl + 10*time.Millisecond)
+	version.Store(2)
+
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.ID != 1 {
+		t.Errorf("expected the stale pokemon right away, got id %d", pokemon.ID)
+	}
+	close(release)
+	client.Wait(context.Background())
+
+	pokemon, err = client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.ID != 2 {
+		t.Errorf("expected the refreshed pokemon, got id %d", pokemon.ID)
+	}
+	if stats := cache.Stats(); stats.StaleHits != 1 {
+		t.Errorf("expected 1 stale hit, got %+v", stats)
+	}
+}
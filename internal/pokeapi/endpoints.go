@@ -0,0 +1,19 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+)
+
+// One page of location areas by offset and limit.
+func (c *Client) GetLocationAreas(ctx context.Context, offset, limit int) (LocationAreas, error) {
+	return fetchJSON[LocationAreas](ctx, c, fmt.Sprintf("%s/location-area?offset=%d&limit=%d", c.baseURL, offset, limit))
+}
+
+func (c *Client) GetLocationArea(ctx context.Context, area string) (LocationEncounterDetails, error) {
+	return fetchJSON[LocationEncounterDetails](ctx, c, c.baseURL+"/location-area/"+area)
+}
+
+func (c *Client) GetPokemon(ctx context.Context, pokemonNameOrId string) (PokemonDetails, error) {
+	return fetchJSON[PokemonDetails](ctx, c, c.baseURL+"/pokemon/"+pokemonNameOrId)
+}
@@ -0,0 +1,75 @@
+package pokeapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinels for errors.Is. The typed errors below match them so callers can tell
+// what went wrong without looking at the message. Their messages have no "error, "
+// prefix, the caller adds that along with what it was doing.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrNetwork     = errors.New("network error")
+	ErrRateLimited = errors.New("rate limited")
+	ErrDecode      = errors.New("decode error")
+	// Returned (wrapped) by every fetch in offline mode when the response is not in the cache.
+	ErrNotCached = errors.New("response is not cached")
+)
+
+// Returned when the API answers with anything but a 2xx, after the transport gave
+// up retrying. A 404 matches ErrNotFound and a 429 ErrRateLimited.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // NOTE: Only set when the API sent a Retry-After header
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("response failed with status %s for %s", e.Status, e.URL)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == 404
+	case ErrRateLimited:
+		return e.StatusCode == 429
+	}
+	return false
+}
+
+// The request never got an answer: DNS, refused connections, timeouts and the like.
+// Unwraps to the cause, so context.Canceled and context.DeadlineExceeded still match.
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("could not fetch %s: %v", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+func (e *NetworkError) Is(target error) bool { return target == ErrNetwork }
+
+// The response (or the cached copy of it) is not the JSON we expected.
+type DecodeError struct {
+	URL    string
+	Cached bool
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Cached {
+		return fmt.Sprintf("could not decode cached data for %s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("could not decode the response from %s: %v", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+func (e *DecodeError) Is(target error) bool { return target == ErrDecode }
@@ -0,0 +1,77 @@
+package pokeapi
+
+import (
+	"context"
+	"sync"
+)
+
+// Deduplicates concurrent fetches of the same URL. The first caller starts the fetch,
+// everyone asking for the same key while it runs waits for it and gets the same value.
+// The zero value is ready to use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	val     any
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Runs "fetch" for "key" unless a fetch for it is already running. The fetch does not
+// belong to any one caller: it keeps going while at least one of them still waits and
+// is cancelled when the last one gives up, so one impatient caller cannot fail the
+// rest.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		// NOTE: Keep the values of the first caller's context (loggers, tracing) but not its cancellation.
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, fetch)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// NOTE: Nobody wants it any more. Later callers start a fresh fetch.
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fetch func(ctx context.Context) (any, error)) {
+	defer f.cancel()
+	f.val, f.err = fetch(ctx)
+	g.mu.Lock()
+	g.forget(key, f)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// Must be called with "g.mu" held.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,109 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentFetchesAreCoalesced(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, server.Client(), nil)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+			if err == nil && pokemon.Name != "pikachu" {
+				err = fmt.Errorf("unexpected pokemon %q", pokemon.Name)
+			}
+			errs <- err
+		}()
+	}
+	// NOTE: Give every caller time to join the running fetch before it finishes.
+	time.Sleep(50 * time.Millisecond)
+	release <- struct{}{}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request to the server, got %d", hits.Load())
+	}
+}
+
+func TestCoalescedFetchOutlivesImpatientCaller(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	impatient, cancel := context.WithCancel(context.Background())
+	impatientErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetPokemon(impatient, "pikachu")
+		impatientErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	patientErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetPokemon(context.Background(), "pikachu")
+		patientErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-impatientErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the impatient caller to be cancelled, got: %v", err)
+	}
+	close(release)
+	if err := <-patientErr; err != nil {
+		t.Errorf("expected the fetch to finish for the caller still waiting, got: %v", err)
+	}
+}
+
+func TestCoalescedFetchIsCancelledWhenEveryoneLeaves(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, server.Client(), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetPokemon(ctx, "pikachu"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got: %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Errorf("expected the request to be cancelled once nobody waited for it")
+	}
+}
@@ -0,0 +1,90 @@
+package pokeapi
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+)
+
+// Location area names found in cached responses. Never touches the network.
+func (c *Client) CachedLocationAreaNames() []string {
+	names := make(map[string]struct{})
+	prefix := c.baseURL + "/location-area"
+	for _, key := range c.cachedKeys() {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if area, ok := strings.CutPrefix(rest, "/"); ok && area != "" {
+			names[area] = struct{}{}
+			continue
+		}
+		// NOTE: Anything else under the prefix is a page of the list.
+		var locationData LocationAreas
+		if c.peekJSON(key, &locationData) {
+			for _, location := range locationData.Results {
+				names[location.Name] = struct{}{}
+			}
+		}
+	}
+	return sortedNames(names)
+}
+
+// Pokemon names found in cached responses, either fetched directly or seen in the
+// encounters of an explored area. Never touches the network.
+func (c *Client) CachedPokemonNames() []string {
+	names := make(map[string]struct{})
+	areaPrefix := c.baseURL + "/location-area/"
+	pokemonPrefix := c.baseURL + "/pokemon/"
+	for _, key := range c.cachedKeys() {
+		if name, ok := strings.CutPrefix(key, pokemonPrefix); ok {
+			// NOTE: Pokemons fetched by ID are skipped, the number is not a name.
+			if _, err := strconv.Atoi(name); err != nil && name != "" {
+				names[name] = struct{}{}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, areaPrefix) {
+			var areaData LocationEncounterDetails
+			if c.peekJSON(key, &areaData) {
+				for _, pokemonEncounter := range areaData.PokemonEncounters {
+					names[pokemonEncounter.Pokemon.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	return sortedNames(names)
+}
+
+// NOTE: Only caches that can be inspected help with completion.
+func (c *Client) cachedKeys() []string {
+	cache, ok := c.cache.(pokecache.InspectableCache)
+	if !ok {
+		return nil
+	}
+	return cache.Keys()
+}
+
+func (c *Client) peekJSON(key string, target any) bool {
+	cache, ok := c.cache.(pokecache.InspectableCache)
+	if !ok {
+		return false
+	}
+	cachedData, ok := cache.Peek(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(cachedData, target) == nil
+}
+
+func sortedNames(names map[string]struct{}) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -1,4 +1,4 @@
-package core
+package pokeapi
 
 type PokemonDetails struct {
 	Abilities []struct {
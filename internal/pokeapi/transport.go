@@ -0,0 +1,214 @@
+package pokeapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// How often and how patiently a failed request is tried again.
+type RetryPolicy struct {
+	MaxRetries int           // NOTE: Retries on top of the first attempt. Zero disables retrying.
+	BaseDelay  time.Duration // NOTE: Delay before the first retry, doubled for every retry after
+	MaxDelay   time.Duration // NOTE: Upper bound of the backoff. A longer Retry-After ends the retries.
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+	}
+}
+
+// Exponential backoff with jitter. The delay for "attempt" (starting at 0) is picked
+// between half and all of BaseDelay * 2^attempt, so clients that failed together do
+// not all come back at the same time.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay // NOTE: "<= 0" catches the shift overflowing
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// An http.RoundTripper that waits for the rate limiter before every attempt and
+// retries 429 and 5xx responses. When it runs out of retries, or the API asks to wait
+// too long, the last response is returned as is, so the caller sees the real status.
+type RetryTransport struct {
+	Base    http.RoundTripper // NOTE: Falls back to http.DefaultTransport
+	Policy  RetryPolicy
+	Limiter *RateLimiter // NOTE: Optional
+	Logger  *slog.Logger // NOTE: Optional
+}
+
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy, limiter *RateLimiter) *RetryTransport {
+	return &RetryTransport{Base: base, Policy: policy, Limiter: limiter}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// NOTE: A request body can only be sent again if it can be rebuilt.
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if t.Limiter != nil {
+			if err := t.Limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := base.RoundTrip(attemptReq)
+		if err != nil || !retryableStatus(resp.StatusCode) || attempt >= t.Policy.MaxRetries || !replayable {
+			return resp, err
+		}
+
+		delay := t.Policy.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if !t.worthWaiting(req.Context(), retryAfter) {
+				return resp, nil // NOTE: The caller sees the status and the Retry-After
+			}
+			delay = retryAfter
+		}
+		if t.Logger != nil {
+			t.Logger.Warn("Retrying request", "url", req.URL.String(), "status", resp.StatusCode, "retry_in", delay)
+		}
+		// NOTE: Drain the body so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// A Retry-After longer than MaxDelay, or than what is left before the deadline of
+// "ctx", is not waited for.
+func (t *RetryTransport) worthWaiting(ctx context.Context, retryAfter time.Duration) bool {
+	if t.Policy.MaxDelay > 0 && retryAfter > t.Policy.MaxDelay {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && retryAfter > time.Until(deadline) {
+		return false
+	}
+	return true
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// Retry-After is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	when, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if when.Before(now) {
+		return 0, true
+	}
+	return when.Sub(now), true
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// A token bucket. Up to "burst" requests go out right away, after that one every
+// 1/perSecond seconds.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// A "perSecond" of zero or less means no limit.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Blocks until the next request may go out or "ctx" is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.perSecond <= 0 {
+		return ctx.Err()
+	}
+	delay := l.reserve()
+	if err := sleep(ctx, delay); err != nil {
+		l.refund()
+		return err
+	}
+	return nil
+}
+
+// Takes a token, going into debt if there is none. The debt is how long the caller
+// has to wait.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.perSecond * float64(time.Second))
+}
+
+// Gives back the token of a caller that stopped waiting.
+func (l *RateLimiter) refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
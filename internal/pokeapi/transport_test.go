@@ -0,0 +1,155 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryingClient(server *httptest.Server, maxRetries int) *Client {
+	policy := RetryPolicy{MaxRetries: maxRetries, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	httpClient := &http.Client{Transport: NewRetryTransport(server.Client().Transport, policy, nil)}
+	return NewClient(server.URL, httpClient, nil)
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name         string
+		failures     int
+		status       int
+		maxRetries   int
+		wantAttempts int32
+		wantStatus   int // NOTE: 0 means the request should succeed
+	}{
+		{name: "recovers from 503", failures: 2, status: http.StatusServiceUnavailable, maxRetries: 3, wantAttempts: 3},
+		{name: "recovers from 429", failures: 1, status: http.StatusTooManyRequests, maxRetries: 3, wantAttempts: 2},
+		{name: "gives up", failures: 10, status: http.StatusBadGateway, maxRetries: 2, wantAttempts: 3, wantStatus: http.StatusBadGateway},
+		{name: "does not retry 404", failures: 10, status: http.StatusNotFound, maxRetries: 3, wantAttempts: 1, wantStatus: http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(attempts.Add(1)) <= c.failures {
+					w.WriteHeader(c.status)
+					return
+				}
+				fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+			}))
+			defer server.Close()
+
+			_, err := newRetryingClient(server, c.maxRetries).GetPokemon(context.Background(), "pikachu")
+			if attempts.Load() != c.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", c.wantAttempts, attempts.Load())
+			}
+			var statusErr *StatusError
+			switch {
+			case c.wantStatus == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case c.wantStatus != 0 && !errors.As(err, &statusErr):
+				t.Errorf("expected a StatusError, got: %v", err)
+			case c.wantStatus != 0 && statusErr.StatusCode != c.wantStatus:
+				t.Errorf("expected status %d, got %d", c.wantStatus, statusErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRetryTransportStopsWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Minute, MaxDelay: time.Minute}
+	httpClient := &http.Client{Transport: NewRetryTransport(server.Client().Transport, policy, nil)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := NewClient(server.URL, httpClient, nil).GetPokemon(ctx, "pikachu")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the backoff to be cut short, took %s", elapsed)
+	}
+}
+
+func TestRetryTransportSkipsLongRetryAfter(t *testing.T) {
+	cases := []struct {
+		name     string
+		maxDelay time.Duration
+		timeout  time.Duration
+	}{
+		{name: "longer than MaxDelay", maxDelay: 10 * time.Millisecond},
+		{name: "longer than the deadline", maxDelay: 5 * time.Minute, timeout: 3 * time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.Header().Set("Retry-After", "120")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: c.maxDelay}
+			client := NewClient(server.URL, &http.Client{Transport: NewRetryTransport(server.Client().Transport, policy, nil)}, nil)
+			client.SetTimeout(c.timeout)
+			start := time.Now()
+			_, err := client.GetPokemon(context.Background(), "pikachu")
+			var statusErr *StatusError
+			if !errors.Is(err, ErrRateLimited) || !errors.As(err, &statusErr) || statusErr.RetryAfter != 120*time.Second {
+				t.Errorf("expected a rate limit error with its Retry-After, got: %v", err)
+			}
+			if attempts.Load() != 1 {
+				t.Errorf("expected 1 attempt, got %d", attempts.Load())
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("expected to give up right away, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", ok: false},
+		{value: "3", expected: 3 * time.Second, ok: true},
+		{value: "-1", ok: false},
+		{value: "Mon, 01 Jan 2024 12:00:30 GMT", expected: 30 * time.Second, ok: true},
+		{value: "Mon, 01 Jan 2024 11:00:00 GMT", expected: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+	for _, c := range cases {
+		actual, ok := parseRetryAfter(c.value, now)
+		if actual != c.expected || ok != c.ok {
+			t.Errorf("%q: expected %s %v, got %s %v", c.value, c.expected, c.ok, actual, ok)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// NOTE: The first request uses the burst, the other three wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the limiter to space out requests, took %s", elapsed)
+	}
+}
@@ -0,0 +1,190 @@
+package pokecache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bundle is a tar.gz of cached responses, meant to carry a cache to machines without
+// network. "manifest.json" comes first and lists every entry with its key, timestamp
+// and validators, the values follow as one file each, exactly as the API sent them.
+// Keys are relative to the base URL of the API they came from, so a bundle made
+// against a mirror works with any other.
+const (
+	bundleManifestName = "manifest.json"
+	bundleVersion      = 1
+)
+
+type bundleManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	BaseURL   string        `json:"base_url,omitempty"` // NOTE: Where the entries were fetched from, for reference
+	Entries   []bundleEntry `json:"entries"`
+}
+
+type bundleEntry struct {
+	Key          string    `json:"key"` // NOTE: Absolute only if it was not under the base URL
+	File         string    `json:"file"`
+	CreatedAt    time.Time `json:"created_at"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// Writes every entry of "cache" to "w" as a bundle and returns how many there were.
+// Expired entries the cache still keeps are included, their timestamps tell. Keys
+// under "baseURL" are stored relative to it.
+func ExportBundle(w io.Writer, cache InspectableCache, baseURL string) (int, error) {
+	keys := cache.Keys()
+	sort.Strings(keys)
+	manifest := bundleManifest{Version: bundleVersion, CreatedAt: time.Now().UTC(), BaseURL: baseURL}
+	var vals [][]byte
+	for _, key := range keys {
+		entry, ok := exportEntry(cache, key)
+		if !ok {
+			continue // NOTE: Expired since Keys was called
+		}
+		manifest.Entries = append(manifest.Entries, bundleEntry{
+			Key:          relativeKey(key, baseURL),
+			File:         fmt.Sprintf("entries/%05d.json", len(vals)),
+			CreatedAt:    entry.CreatedAt,
+			ETag:         entry.ETag,
+			LastModified: entry.LastModified,
+		})
+		vals = append(vals, entry.Val)
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	if err := writeBundleFile(tw, bundleManifestName, manifestData, manifest.CreatedAt); err != nil {
+		return 0, err
+	}
+	for i, entry := range manifest.Entries {
+		if err := writeBundleFile(tw, entry.File, vals[i], entry.CreatedAt); err != nil {
+			return 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return len(manifest.Entries), nil
+}
+
+func relativeKey(key, baseURL string) string {
+	if baseURL == "" {
+		return key
+	}
+	if relative, ok := strings.CutPrefix(key, baseURL+"/"); ok {
+		return relative
+	}
+	return key
+}
+
+// Relative keys are put back under "baseURL", absolute ones are kept as they are.
+func absoluteKey(key, baseURL string) string {
+	if baseURL == "" || strings.Contains(key, "://") {
+		return key
+	}
+	return baseURL + "/" + key
+}
+
+// Caches that can tell when an entry was added hand that over, others are exported
+// as if everything was cached just now.
+func exportEntry(cache InspectableCache, key string) (Entry, bool) {
+	if revalidating, ok := cache.(RevalidatingCache); ok {
+		entry, _, ok := revalidating.Lookup(key)
+		return entry, ok
+	}
+	val, ok := cache.Peek(key)
+	return Entry{Val: val, CreatedAt: time.Now()}, ok
+}
+
+func writeBundleFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Adds every entry of the bundle in "r" to "cache", keeping their original timestamps,
+// and returns how many there were. Entries too old for the cache's TTL are added all
+// the same, offline they are served however old they are. Relative keys are put under
+// "baseURL", whatever the bundle was made against.
+func ImportBundle(r io.Reader, cache Cache, baseURL string) (int, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("not a bundle: %w", err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != bundleManifestName {
+		return 0, errors.New("not a bundle: the manifest is missing")
+	}
+	var manifest bundleManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return 0, fmt.Errorf("not a bundle: broken manifest: %w", err)
+	}
+	if manifest.Version != bundleVersion {
+		return 0, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+	entries := make(map[string]bundleEntry, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		entries[entry.File] = entry
+	}
+
+	revalidating, _ := cache.(RevalidatingCache)
+	imported := 0
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+		entry, ok := entries[header.Name]
+		if !ok {
+			continue // NOTE: Not ours, bundles may be repacked by hand
+		}
+		val, err := io.ReadAll(tr)
+		if err != nil {
+			return imported, err
+		}
+		key := absoluteKey(entry.Key, baseURL)
+		if revalidating != nil {
+			revalidating.AddEntry(key, Entry{
+				Val:          val,
+				ETag:         entry.ETag,
+				LastModified: entry.LastModified,
+				CreatedAt:    entry.CreatedAt,
+			})
+		} else {
+			cache.Add(key, val)
+		}
+		imported++
+	}
+	return imported, nil
+}
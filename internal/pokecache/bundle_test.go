@@ -0,0 +1,90 @@
+package pokecache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	source := NewPokeCache(time.Hour, WithoutReaping(), WithCompression(CompressionGzip))
+	createdAt := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	source.AddEntry("https://example.com/a", Entry{Val: []byte(`{"a": 1}`), ETag: `"a1"`, CreatedAt: createdAt})
+	source.Add("https://example.com/b", []byte(strings.Repeat("b", 1000)))
+
+	var buf bytes.Buffer
+	exported, err := ExportBundle(&buf, source, "https://example.com")
+	if err != nil || exported != 2 {
+		t.Fatalf("expected 2 exported entries, got %d and %v", exported, err)
+	}
+
+	target := NewLRUCache(0, time.Hour)
+	imported, err := ImportBundle(&buf, target, "https://example.com")
+	if err != nil || imported != 2 {
+		t.Fatalf("expected 2 imported entries, got %d and %v", imported, err)
+	}
+	entry, fresh, ok := target.Lookup("https://example.com/a")
+	if !ok || !fresh || string(entry.Val) != `{"a": 1}` || entry.ETag != `"a1"` || !entry.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected the entry with its ETag and timestamp, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+	if val, ok := target.Get("https://example.com/b"); !ok || len(val) != 1000 {
+		t.Errorf("expected the value to come back uncompressed, got %d bytes", len(val))
+	}
+}
+
+func TestImportRejectsOtherFiles(t *testing.T) {
+	cache := NewPokeCache(time.Hour, WithoutReaping())
+	if _, err := ImportBundle(strings.NewReader("not a bundle"), cache, "https://example.com"); err == nil {
+		t.Errorf("expected an error for something that is not a bundle")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected nothing to be imported, got %v", cache.Keys())
+	}
+}
+
+func TestImportOldBundleServedOffline(t *testing.T) {
+	source := NewPokeCache(90*24*time.Hour, WithoutReaping())
+	createdAt := time.Now().Add(-30 * 24 * time.Hour)
+	source.AddEntry("https://example.com/a", Entry{Val: []byte(`{"a": 1}`), CreatedAt: createdAt})
+
+	var buf bytes.Buffer
+	if _, err := ExportBundle(&buf, source, "https://example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target, err := NewPersistentPokeCache(t.TempDir(), time.Hour, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	target.SetOffline(true)
+	if imported, err := ImportBundle(&buf, target, "https://example.com"); err != nil || imported != 1 {
+		t.Fatalf("expected 1 imported entry, got %d and %v", imported, err)
+	}
+	entry, fresh, ok := target.Lookup("https://example.com/a")
+	if !ok || fresh || string(entry.Val) != `{"a": 1}` {
+		t.Errorf("expected the month old entry to be served offline, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+}
+
+func TestBundleKeysFollowTheBaseURL(t *testing.T) {
+	source := NewPokeCache(time.Hour, WithoutReaping())
+	source.Add("http://127.0.0.1:8080/api/v2/pokemon/pikachu", []byte("pikachu"))
+	source.Add("https://elsewhere.example.com/pokemon/eevee", []byte("eevee"))
+
+	var buf bytes.Buffer
+	if _, err := ExportBundle(&buf, source, "http://127.0.0.1:8080/api/v2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := NewPokeCache(time.Hour, WithoutReaping())
+	if _, err := ImportBundle(&buf, target, "https://pokeapi.co/api/v2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, ok := target.Get("https://pokeapi.co/api/v2/pokemon/pikachu"); !ok || string(val) != "pikachu" {
+		t.Errorf("expected the entry under the importing base URL, got %q %v and keys %v", val, ok, target.Keys())
+	}
+	if _, ok := target.Get("https://elsewhere.example.com/pokemon/eevee"); !ok {
+		t.Errorf("expected a key outside the base URL to be kept as is, got keys %v", target.Keys())
+	}
+}
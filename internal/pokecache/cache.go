@@ -0,0 +1,69 @@
+package pokecache
+
+// What the API client needs from a cache. PokeCache (in memory or on disk), LRUCache
+// and NopCache all implement it, tests can bring their own.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Add(key string, val []byte)
+	Delete(key string) bool
+	Close() error
+}
+
+// A cache that can also be looked into without touching its counters. Completion and
+// the `cache` command need this, a plain Cache still works without them.
+type InspectableCache interface {
+	Cache
+	Peek(key string) ([]byte, bool)
+	Keys() []string
+	Len() int
+	Clear()
+	Stats() Stats
+}
+
+// A cache that keeps expired entries with an ETag or Last-Modified around, so the client
+// can revalidate them with a conditional request instead of downloading them again.
+type RevalidatingCache interface {
+	Cache
+	// Finds "key" whether it expired or not, without counting towards Stats. Only a
+	// "fresh" entry can be used as is. An expired one can be revalidated, or served
+	// while it is refreshed if it is Stale.
+	Lookup(key string) (entry Entry, fresh bool, ok bool)
+	// Like Add, with the validators the API sent so the entry can be revalidated once
+	// it expires.
+	AddEntry(key string, entry Entry)
+	// The API answered 304 Not Modified for "key": it is fresh again. Validators that
+	// came with the 304 replace the stored ones. Reports whether there was an entry.
+	Refresh(key, etag, lastModified string) bool
+}
+
+// Names accepted by the --cache flag.
+const (
+	BackendMemory = "memory"
+	BackendLRU    = "lru"
+	BackendDisk   = "disk"
+	BackendNone   = "none"
+)
+
+var Backends = []string{BackendDisk, BackendMemory, BackendLRU, BackendNone}
+
+// Caches nothing. Every Get is a miss.
+type NopCache struct{}
+
+func (NopCache) Get(string) ([]byte, bool)  { return nil, false }
+func (NopCache) Add(string, []byte)         {}
+func (NopCache) Delete(string) bool         { return false }
+func (NopCache) Close() error               { return nil }
+func (NopCache) Peek(string) ([]byte, bool) { return nil, false }
+func (NopCache) Keys() []string             { return nil }
+func (NopCache) Len() int                   { return 0 }
+func (NopCache) Clear()                     {}
+func (NopCache) Stats() Stats               { return Stats{Backend: BackendNone, Compression: CompressionNone} }
+
+var (
+	_ InspectableCache = (*PokeCache)(nil)
+	_ InspectableCache = (*LRUCache)(nil)
+	_ InspectableCache = NopCache{}
+
+	_ RevalidatingCache = (*PokeCache)(nil)
+	_ RevalidatingCache = (*LRUCache)(nil)
+)
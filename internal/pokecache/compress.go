@@ -0,0 +1,61 @@
+package pokecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Names accepted by the --cache-compression flag.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+var Compressions = []string{CompressionNone, CompressionGzip}
+
+// Checks a compression name from the user. An empty name means no compression.
+func ParseCompression(name string) (string, error) {
+	switch name {
+	case "", CompressionNone:
+		return CompressionNone, nil
+	case CompressionGzip:
+		return CompressionGzip, nil
+	}
+	return "", fmt.Errorf("error, unknown compression %q, expected one of %s", name, strings.Join(Compressions, ", "))
+}
+
+// Compresses "val" with "compression" and returns what to store along with its
+// encoding. Values that do not get smaller are stored as they are, with no encoding,
+// so reading them back costs nothing.
+func compress(compression string, val []byte) ([]byte, string) {
+	if compression != CompressionGzip {
+		return val, ""
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(val); err != nil {
+		return val, ""
+	}
+	if err := zw.Close(); err != nil || buf.Len() >= len(val) {
+		return val, ""
+	}
+	return buf.Bytes(), CompressionGzip
+}
+
+func decompress(encoding string, stored []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return stored, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(stored))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
@@ -0,0 +1,67 @@
+package pokecache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressedPersistentCache(t *testing.T) {
+	dir := t.TempDir()
+	val := []byte(strings.Repeat(`{"front_default": "https://example.com/sprite.png"}`, 100))
+	cache, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping(), WithCompression(CompressionGzip))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com/big", val)
+	cache.Add("https://example.com/small", []byte("1"))
+
+	if got, ok := cache.Get("https://example.com/big"); !ok || !bytes.Equal(got, val) {
+		t.Errorf("expected the value back as it was added")
+	}
+	stats := cache.Stats()
+	if stats.UncompressedBytes != int64(len(val))+1 || stats.CompressionRatio() < 5 {
+		t.Errorf("expected the values to shrink, got %+v ratio %.1f", stats, stats.CompressionRatio())
+	}
+	if stats.MemoryBytes >= int64(len(val)) {
+		t.Errorf("expected the memory footprint to shrink, got %d bytes", stats.MemoryBytes)
+	}
+
+	// NOTE: Entries written with gzip still read fine after compression is turned off.
+	reopened, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, ok := reopened.Get("https://example.com/big"); !ok || !bytes.Equal(got, val) {
+		t.Errorf("expected the compressed value to be read back from disk")
+	}
+	if got, ok := reopened.Get("https://example.com/small"); !ok || string(got) != "1" {
+		t.Errorf("expected the small value, which is not worth compressing, got %q", got)
+	}
+}
+
+func TestCompressedLRUCache(t *testing.T) {
+	val := []byte(strings.Repeat("pikachu ", 1000))
+	cache := NewLRUCache(1024, time.Minute)
+	cache.SetCompression(CompressionGzip)
+	cache.Add("https://example.com", val)
+	// NOTE: Only fits because the limit counts compressed bytes.
+	if got, ok := cache.Get("https://example.com"); !ok || !bytes.Equal(got, val) {
+		t.Errorf("expected the value back as it was added")
+	}
+	if stats := cache.Stats(); stats.CompressedBytes > 1024 || stats.UncompressedBytes != int64(len(val)) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for _, name := range []string{"", "none", "gzip"} {
+		if _, err := ParseCompression(name); err != nil {
+			t.Errorf("%q: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := ParseCompression("zstd"); err == nil {
+		t.Errorf("expected an error for an unsupported compression")
+	}
+}
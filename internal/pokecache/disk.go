@@ -0,0 +1,274 @@
+package pokecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// What gets written to disk for every entry. The key is stored alongside the value
+// since file names are only a hash of it.
+type diskEntry struct {
+	Key          string    `json:"key"`
+	CreatedAt    time.Time `json:"created_at"`
+	Val          []byte    `json:"val"`
+	Encoding     string    `json:"encoding,omitempty"`
+	Size         int       `json:"size,omitempty"` // NOTE: Length of "Val" before compression
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+func newDiskEntry(key string, cacheEntry pokeCacheEntry) diskEntry {
+	return diskEntry{
+		Key:          key,
+		CreatedAt:    cacheEntry.createdAt,
+		Val:          cacheEntry.val,
+		Encoding:     cacheEntry.encoding,
+		Size:         cacheEntry.size,
+		ETag:         cacheEntry.etag,
+		LastModified: cacheEntry.lastModified,
+	}
+}
+
+func (e diskEntry) cacheEntry() pokeCacheEntry {
+	return pokeCacheEntry{
+		createdAt:    e.CreatedAt,
+		val:          e.Val,
+		encoding:     e.Encoding,
+		size:         e.rawSize(),
+		etag:         e.ETag,
+		lastModified: e.LastModified,
+	}
+}
+
+// Entries written before compression existed have no "Size".
+func (e diskEntry) rawSize() int {
+	if e.Size == 0 && e.Encoding == "" {
+		return len(e.Val)
+	}
+	return e.Size
+}
+
+type diskIndexEntry struct {
+	file      string
+	size      int64
+	valBytes  int64 // NOTE: Stored value only, compressed or not
+	rawBytes  int64
+	createdAt time.Time
+	validated bool // NOTE: Has an ETag or Last-Modified
+}
+
+func newDiskIndexEntry(file string, size int64, entry diskEntry) diskIndexEntry {
+	return diskIndexEntry{
+		file:      file,
+		size:      size,
+		valBytes:  int64(len(entry.Val)),
+		rawBytes:  int64(entry.rawSize()),
+		createdAt: entry.CreatedAt,
+		validated: entry.ETag != "" || entry.LastModified != "",
+	}
+}
+
+// One JSON file per entry under "dir". The index is rebuilt from the files on open,
+// so timestamps survive restarts.
+type diskStore struct {
+	mu         sync.Mutex
+	dir        string
+	maxBytes   int64
+	index      map[string]diskIndexEntry
+	totalBytes int64
+	evictions  int64 // NOTE: Entries dropped for being over "maxBytes"
+}
+
+// Broken files are removed on open. Expired ones are kept as a fallback for when the
+// API cannot be reached, they are only dropped when replaced or to make room.
+func openDiskStore(dir string, maxBytes int64) (*diskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error, failed to create cache directory %s: %w", dir, err)
+	}
+	store := &diskStore{
+		dir:      dir,
+		maxBytes: maxBytes,
+		index:    make(map[string]diskIndexEntry),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		entry, size, err := readDiskEntry(file)
+		if err != nil {
+			os.Remove(file)
+			continue
+		}
+		store.index[entry.Key] = newDiskIndexEntry(file, size, entry)
+		store.totalBytes += size
+	}
+	store.mu.Lock()
+	store.evictLocked()
+	store.mu.Unlock()
+	return store, nil
+}
+
+func readDiskEntry(file string) (diskEntry, int64, error) {
+	var entry diskEntry
+	byteData, err := os.ReadFile(file)
+	if err != nil {
+		return entry, 0, err
+	}
+	err = json.Unmarshal(byteData, &entry)
+	return entry, int64(len(byteData)), err
+}
+
+func (ds *diskStore) fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(ds.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (ds *diskStore) put(entry diskEntry) error {
+	key := entry.Key
+	byteData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file := ds.fileName(key)
+	// Write to a temporary file first so a crash never leaves a half written entry behind.
+	tmp, err := os.CreateTemp(ds.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(byteData); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if old, ok := ds.index[key]; ok {
+		ds.totalBytes -= old.size
+	}
+	size := int64(len(byteData))
+	ds.index[key] = newDiskIndexEntry(file, size, entry)
+	ds.totalBytes += size
+	ds.evictLocked()
+	return nil
+}
+
+func (ds *diskStore) get(key string) (diskEntry, bool) {
+	ds.mu.Lock()
+	indexEntry, ok := ds.index[key]
+	ds.mu.Unlock()
+	if !ok {
+		return diskEntry{}, false
+	}
+	entry, _, err := readDiskEntry(indexEntry.file)
+	if err != nil || entry.Key != key {
+		ds.remove(key)
+		return diskEntry{}, false
+	}
+	return entry, true
+}
+
+// Keys of the entries "keep" is true for. Only the index is looked at, values are not
+// read from disk for this.
+func (ds *diskStore) keys(keep func(createdAt time.Time, validated bool) bool) []string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	keys := make([]string, 0, len(ds.index))
+	for k, indexEntry := range ds.index {
+		if keep(indexEntry.createdAt, indexEntry.validated) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (ds *diskStore) remove(key string) bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.removeLocked(key)
+}
+
+func (ds *diskStore) removeLocked(key string) bool {
+	indexEntry, ok := ds.index[key]
+	if !ok {
+		return false
+	}
+	os.Remove(indexEntry.file)
+	ds.totalBytes -= indexEntry.size
+	delete(ds.index, key)
+	return true
+}
+
+func (ds *diskStore) clear() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	for k := range ds.index {
+		ds.removeLocked(k)
+	}
+}
+
+func (ds *diskStore) stats() (entries int, bytes int64, evictions int64) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return len(ds.index), ds.totalBytes, ds.evictions
+}
+
+// Size of every stored value before and after compression.
+func (ds *diskStore) valueBytes() (raw, stored int64) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	for _, indexEntry := range ds.index {
+		raw += indexEntry.rawBytes
+		stored += indexEntry.valBytes
+	}
+	return raw, stored
+}
+
+// Drops the oldest entries until we fit in "maxBytes" again. A limit of zero or
+// less means there is no limit.
+func (ds *diskStore) evictLocked() {
+	if ds.maxBytes <= 0 || ds.totalBytes <= ds.maxBytes {
+		return
+	}
+	keys := make([]string, 0, len(ds.index))
+	for k := range ds.index {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return ds.index[keys[i]].createdAt.Before(ds.index[keys[j]].createdAt)
+	})
+	for _, k := range keys {
+		if ds.totalBytes <= ds.maxBytes {
+			break
+		}
+		ds.removeLocked(k)
+		ds.evictions++
+	}
+}
+
+// Default location of the on-disk cache. Honours $XDG_CACHE_HOME.
+func DefaultCacheDir() (string, error) {
+	cacheHome, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "pokedexcli"), nil
+}
@@ -0,0 +1,160 @@
+package pokecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersistentSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+
+	reopened, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	val, ok := reopened.Get("https://example.com")
+	if !ok {
+		t.Errorf("expected to find key after reopening")
+		return
+	}
+	if string(val) != "testdata" {
+		t.Errorf("expected to find value")
+	}
+}
+
+func TestPersistentExpiresAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewPersistentPokeCache(dir, 50*time.Millisecond, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	reopened, err := NewPersistentPokeCache(dir, 50*time.Millisecond, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reopened.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+	}
+}
+
+func TestPersistentEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewPersistentPokeCache(dir, time.Minute, 150, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com/old", []byte("olddata"))
+	time.Sleep(time.Millisecond)
+	cache.Add("https://example.com/new", []byte("newdata"))
+
+	reopened, err := NewPersistentPokeCache(dir, time.Minute, 150, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reopened.Get("https://example.com/old"); ok {
+		t.Errorf("expected the oldest entry to be evicted")
+	}
+	if _, ok := reopened.Get("https://example.com/new"); !ok {
+		t.Errorf("expected the newest entry to be kept")
+	}
+}
+
+func TestPersistentKeepsValidatorsForRevalidation(t *testing.T) {
+	dir := t.TempDir()
+	const ttl = 50 * time.Millisecond
+	cache, err := NewPersistentPokeCache(dir, ttl, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.AddEntry("https://example.com/etag", Entry{Val: []byte("a"), ETag: `"v1"`})
+	cache.Add("https://example.com/plain", []byte("b"))
+
+	time.Sleep(ttl + 50*time.Millisecond)
+
+	reopened, err := NewPersistentPokeCache(dir, ttl, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reopened.Get("https://example.com/etag"); ok {
+		t.Errorf("expected the expired entry to not be served as is")
+	}
+	entry, fresh, ok := reopened.Lookup("https://example.com/etag")
+	if !ok || fresh || entry.ETag != `"v1"` || string(entry.Val) != "a" {
+		t.Errorf("expected a stale entry with its ETag, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+	if _, ok := reopened.Get("https://example.com/plain"); ok {
+		t.Errorf("expected the expired entry without validators to not be served as is")
+	}
+
+	if !reopened.Refresh("https://example.com/etag", `"v2"`, "") {
+		t.Fatalf("expected Refresh to find the entry")
+	}
+	if val, ok := reopened.Get("https://example.com/etag"); !ok || string(val) != "a" {
+		t.Errorf("expected the refreshed entry to be fresh, got %q %v", val, ok)
+	}
+	if entry, _, _ := reopened.Lookup("https://example.com/etag"); entry.ETag != `"v2"` {
+		t.Errorf("expected the new ETag to replace the old one, got %q", entry.ETag)
+	}
+}
+
+func TestPersistentKeepsExpiredFilesForOffline(t *testing.T) {
+	dir := t.TempDir()
+	const ttl = 50 * time.Millisecond
+	cache, err := NewPersistentPokeCache(dir, ttl, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+
+	time.Sleep(ttl + 50*time.Millisecond)
+
+	// NOTE: Opening the cache must not throw away what an offline run still needs.
+	if _, err := NewPersistentPokeCache(dir, ttl, 0, WithoutReaping()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	offline, err := NewPersistentPokeCache(dir, ttl, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	offline.SetOffline(true)
+	entry, fresh, ok := offline.Lookup("https://example.com")
+	if !ok || fresh || string(entry.Val) != "testdata" {
+		t.Errorf("expected the expired entry offline, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+
+	offline.SetOffline(false)
+	if _, ok := offline.Get("https://example.com"); ok {
+		t.Errorf("expected the expired entry to not be served as is once online")
+	}
+	if entry, fresh, ok := offline.Lookup("https://example.com"); !ok || fresh || entry.Stale {
+		t.Errorf("expected the expired entry to stay on disk as a fallback, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+}
+
+func TestPersistentKeysSkipExpiredEntries(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	cache, err := NewPersistentPokeCache(t.TempDir(), ttl, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com/old", []byte("old"))
+
+	time.Sleep(ttl + 10*time.Millisecond)
+
+	cache.Add("https://example.com/new", []byte("new"))
+	if keys := cache.Keys(); len(keys) != 1 || keys[0] != "https://example.com/new" {
+		t.Errorf("expected only the fresh key, got %v", keys)
+	}
+	if stats := cache.Stats(); cache.Len() != 1 || stats.Entries != 1 {
+		t.Errorf("expected 1 entry, got Len %d and %+v", cache.Len(), stats)
+	}
+}
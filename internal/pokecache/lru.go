@@ -0,0 +1,304 @@
+package pokecache
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// An in-memory cache that never holds more than "maxBytes" of values. When it is full
+// the least recently used entries make room. Nothing runs in the background, expired
+// entries are dropped when they are read.
+type LRUCache struct {
+	mu            sync.Mutex
+	maxBytes      int64
+	ttl           time.Duration // NOTE: Zero means entries only leave when evicted
+	bytes         int64
+	order         *list.List // NOTE: Front is the most recently used
+	entries       map[string]*list.Element
+	logger        *slog.Logger
+	hits          int64
+	misses        int64
+	evictions     int64
+	revalidations int64
+	maxStale      time.Duration
+	offline       bool
+	staleHits     int64
+	compression   string
+	rawBytes      int64 // NOTE: "bytes" before compression
+}
+
+type lruEntry struct {
+	key          string
+	val          []byte // NOTE: Compressed if "encoding" is set
+	encoding     string
+	size         int
+	createdAt    time.Time
+	etag         string
+	lastModified string
+}
+
+func (e *lruEntry) export() (Entry, error) {
+	val, err := decompress(e.encoding, e.val)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Val: val, ETag: e.etag, LastModified: e.lastModified, CreatedAt: e.createdAt}, nil
+}
+
+// A "maxBytes" of zero or less means no limit, which makes it a plain memory cache.
+func NewLRUCache(maxBytes int64, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		maxBytes:    maxBytes,
+		ttl:         ttl,
+		compression: CompressionNone,
+		order:       list.New(),
+		entries:     make(map[string]*list.Element),
+		logger:      slog.Default(),
+	}
+}
+
+// See WithCompression. The size limit applies to the compressed values.
+func (c *LRUCache) SetCompression(compression string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compression = compression
+}
+
+// See PokeCache.SetOffline.
+func (c *LRUCache) SetOffline(offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offline = offline
+}
+
+// See WithMaxStale.
+func (c *LRUCache) SetMaxStale(maxStale time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxStale = maxStale
+}
+
+func (c *LRUCache) SetLogger(logger *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logger
+}
+
+func (c *LRUCache) Add(key string, val []byte) {
+	c.AddEntry(key, Entry{Val: val})
+}
+
+// See RevalidatingCache.
+func (c *LRUCache) AddEntry(key string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger.Debug("Adding new cache entry...", "key", key)
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+	stored, encoding := compress(c.compression, entry.Val)
+	if c.maxBytes > 0 && int64(len(stored)) > c.maxBytes {
+		c.logger.Debug("Cache entry is larger than the whole cache", "key", key, "bytes", len(stored))
+		return
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{
+		key:          key,
+		val:          stored,
+		encoding:     encoding,
+		size:         len(entry.Val),
+		createdAt:    entry.CreatedAt,
+		etag:         entry.ETag,
+		lastModified: entry.LastModified,
+	})
+	c.bytes += int64(len(stored))
+	c.rawBytes += int64(len(entry.Val))
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.removeLocked(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger.Debug("Getting cache entry....", "key", key)
+	entry, fresh := c.lookupLocked(key)
+	if entry != nil && !fresh && c.staleLocked(entry, time.Now()) {
+		c.staleHits++
+		c.logger.Debug("Cache entry is stale.", "key", key)
+		return nil, false
+	}
+	if entry == nil || !fresh {
+		c.misses++
+		c.logger.Debug("Cache entry is outdated or does not exist.", "key", key)
+		return nil, false
+	}
+	val, err := decompress(entry.encoding, entry.val)
+	if err != nil {
+		c.logger.Warn("Dropping cache entry that cannot be decompressed", "key", key, "error", err)
+		c.removeLocked(c.entries[key])
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(c.entries[key])
+	return val, true
+}
+
+// Like Get but without counting towards Stats or making the entry recently used.
+func (c *LRUCache) Peek(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, fresh := c.lookupLocked(key)
+	if entry == nil || !fresh {
+		return nil, false
+	}
+	val, err := decompress(entry.encoding, entry.val)
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// See RevalidatingCache. Does not make the entry recently used.
+func (c *LRUCache) Lookup(key string) (Entry, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, fresh := c.lookupLocked(key)
+	if entry == nil {
+		return Entry{}, false, false
+	}
+	exported, err := entry.export()
+	if err != nil {
+		c.removeLocked(c.entries[key])
+		return Entry{}, false, false
+	}
+	exported.Stale = !fresh && c.staleLocked(entry, time.Now())
+	return exported, fresh, true
+}
+
+// See RevalidatingCache. The entry becomes the most recently used.
+func (c *LRUCache) Refresh(key, etag, lastModified string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, _ := c.lookupLocked(key)
+	if entry == nil {
+		return false
+	}
+	c.logger.Debug("Revalidated cache entry", "key", key)
+	if etag != "" {
+		entry.etag = etag
+	}
+	if lastModified != "" {
+		entry.lastModified = lastModified
+	}
+	entry.createdAt = time.Now()
+	c.order.MoveToFront(c.entries[key])
+	c.revalidations++
+	return true
+}
+
+func (c *LRUCache) staleLocked(entry *lruEntry, now time.Time) bool {
+	return !now.After(entry.createdAt.Add(c.ttl + c.maxStale))
+}
+
+// Expired entries with an ETag or Last-Modified stay around for DefaultRetention so
+// they can be revalidated, unless they get evicted for space first. Entries within the
+// max-stale window stay too.
+func (c *LRUCache) lookupLocked(key string) (entry *lruEntry, fresh bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry = element.Value.(*lruEntry)
+	if c.ttl <= 0 {
+		return entry, true
+	}
+	now := time.Now()
+	if !now.After(entry.createdAt.Add(c.ttl)) {
+		return entry, true
+	}
+	hasValidator := entry.etag != "" || entry.lastModified != ""
+	if c.offline || c.staleLocked(entry, now) {
+		return entry, false
+	}
+	if !hasValidator || now.After(entry.createdAt.Add(c.ttl+DefaultRetention)) {
+		c.removeLocked(element)
+		c.evictions++
+		return nil, false
+	}
+	return entry, false
+}
+
+func (c *LRUCache) removeLocked(element *list.Element) {
+	entry := c.order.Remove(element).(*lruEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.val))
+	c.rawBytes -= int64(entry.size)
+}
+
+func (c *LRUCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if ok {
+		c.removeLocked(element)
+	}
+	return ok
+}
+
+// Most recently used first.
+func (c *LRUCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.entries))
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*lruEntry).key)
+	}
+	return keys
+}
+
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *LRUCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.bytes = 0
+	c.rawBytes = 0
+}
+
+func (c *LRUCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Backend:           BackendLRU,
+		Entries:           len(c.entries),
+		MemoryEntries:     len(c.entries),
+		Hits:              c.hits,
+		Misses:            c.misses,
+		Evictions:         c.evictions,
+		Revalidations:     c.revalidations,
+		StaleHits:         c.staleHits,
+		MemoryBytes:       c.bytes,
+		Compression:       c.compression,
+		UncompressedBytes: c.rawBytes,
+		CompressedBytes:   c.bytes,
+		MaxBytes:          c.maxBytes,
+	}
+}
+
+// Nothing to stop, there is no background goroutine.
+func (c *LRUCache) Close() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package pokecache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(10, 0)
+	cache.Add("a", []byte("1111"))
+	cache.Add("b", []byte("2222"))
+	cache.Get("a") // NOTE: "b" is now the least recently used
+	cache.Add("c", []byte("3333"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if strings.Join(cache.Keys(), ",") != "c,a" {
+		t.Errorf("expected keys c,a, got %v", cache.Keys())
+	}
+	stats := cache.Stats()
+	if stats.MemoryBytes != 8 || stats.Evictions != 1 {
+		t.Errorf("expected 8 bytes and 1 eviction, got %+v", stats)
+	}
+}
+
+func TestLRUSkipsOversizedEntries(t *testing.T) {
+	cache := NewLRUCache(4, 0)
+	cache.Add("a", []byte("11"))
+	cache.Add("huge", []byte("123456789"))
+	if _, ok := cache.Get("huge"); ok {
+		t.Errorf("expected an entry larger than the cache to be skipped")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("expected the entries already there to stay")
+	}
+}
+
+func TestLRUExpiresOnRead(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	cache := NewLRUCache(0, ttl)
+	cache.Add("a", []byte("1"))
+	time.Sleep(ttl + 10*time.Millisecond)
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected a to be expired")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected the expired entry to be dropped, got %v", cache.Keys())
+	}
+}
@@ -1,70 +1,480 @@
 package pokecache
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// How long an expired entry with an ETag or Last-Modified is kept for revalidation.
+const DefaultRetention = 7 * 24 * time.Hour
+
 type pokeCacheEntry struct {
-	createdAt time.Time
-	val       []byte
+	createdAt    time.Time
+	val          []byte // NOTE: Compressed if "encoding" is set
+	encoding     string
+	size         int // NOTE: Length of the value before compression
+	etag         string
+	lastModified string
+}
+
+// A cached response along with what is needed to revalidate it once it expires.
+type Entry struct {
+	Val          []byte
+	ETag         string
+	LastModified string
+	CreatedAt    time.Time // NOTE: Zero means now when adding
+	Stale        bool      // NOTE: Expired but within the max-stale window, may be served while it is refreshed
+}
+
+func (e pokeCacheEntry) hasValidator() bool {
+	return e.etag != "" || e.lastModified != ""
+}
+
+func (e pokeCacheEntry) export() (Entry, error) {
+	val, err := decompress(e.encoding, e.val)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Val: val, ETag: e.etag, LastModified: e.lastModified, CreatedAt: e.createdAt}, nil
 }
+
 type PokeCache struct {
 	mu             sync.RWMutex
 	entries        map[string]pokeCacheEntry
 	expiryInterval time.Duration
+	store          *diskStore // NOTE: nil unless the cache is persistent
+	logger         *slog.Logger
+	hits           atomic.Int64
+	misses         atomic.Int64
+	evictions      atomic.Int64 // NOTE: Expired entries dropped from memory. Disk keeps its own count.
+	revalidations  atomic.Int64
+	retention      time.Duration
+	maxStale       time.Duration
+	offline        atomic.Bool // NOTE: Keep everything, there is no way to get a newer copy
+	compression    string
+	staleHits      atomic.Int64
+	reap           bool
+	stop           chan struct{} // NOTE: Closed by Close to end reapLoop
+	reaperDone     chan struct{}
+	closeOnce      sync.Once
+}
+
+// Changes how a cache made by NewPokeCache or NewPersistentPokeCache behaves.
+type Option func(*PokeCache)
+
+// No background goroutine drops expired entries. They are still never served, Get
+// checks expiry on read and drops them then. Meant for tests and for embedding the
+// cache somewhere a goroutine per cache is not wanted.
+func WithoutReaping() Option {
+	return func(pk *PokeCache) {
+		pk.reap = false
+	}
+}
+
+// How long expired entries that can be revalidated (they have an ETag or Last-Modified)
+// are kept after they expire. Zero drops them right away like any other entry.
+func WithRetention(retention time.Duration) Option {
+	return func(pk *PokeCache) {
+		pk.retention = retention
+	}
+}
+
+// How long an expired entry may still be served while it is refreshed in the
+// background (stale-while-revalidate). Zero, the default, never serves expired entries.
+func WithMaxStale(maxStale time.Duration) Option {
+	return func(pk *PokeCache) {
+		pk.maxStale = maxStale
+	}
+}
+
+// Compresses values before they are kept, in memory and on disk. See Compressions.
+// Entries written with another compression, or none, can still be read.
+func WithCompression(compression string) Option {
+	return func(pk *PokeCache) {
+		pk.compression = compression
+	}
+}
+
+// What Stats reports. Entries counts every key once, whether it is in memory, on disk
+// or both.
+type Stats struct {
+	Backend       string `json:"backend"`
+	Entries       int    `json:"entries"`
+	MemoryEntries int    `json:"memory_entries"`
+	DiskEntries   int    `json:"disk_entries"`
+	Hits          int64  `json:"hits"`
+	Misses        int64  `json:"misses"`
+	Evictions     int64  `json:"evictions"`
+	Revalidations int64  `json:"revalidations"` // NOTE: Expired entries the API said are still good
+	StaleHits     int64  `json:"stale_hits"`    // NOTE: Expired entries served while being refreshed
+	Compression   string `json:"compression"`
+	// NOTE: Size of every value before and after compression, whatever layer holds all of them
+	UncompressedBytes int64 `json:"uncompressed_bytes"`
+	CompressedBytes   int64 `json:"compressed_bytes"`
+	MemoryBytes       int64 `json:"memory_bytes"`
+	DiskBytes         int64 `json:"disk_bytes"`
+	MaxBytes          int64 `json:"max_bytes,omitempty"` // NOTE: Size limit, of the disk for a persistent cache
+	Persistent        bool  `json:"persistent"`
+}
+
+// How many times smaller compression made the values, 1 if it did nothing.
+func (s Stats) CompressionRatio() float64 {
+	if s.CompressedBytes == 0 {
+		return 1
+	}
+	return float64(s.UncompressedBytes) / float64(s.CompressedBytes)
 }
 
 func (pk *PokeCache) reapLoop() {
+	defer close(pk.reaperDone)
 	ticker := time.NewTicker(pk.expiryInterval)
 	defer ticker.Stop()
-	for c := range ticker.C {
-		pk.mu.Lock()
-		for k, v := range pk.entries {
-			if c.After(v.createdAt.Add(pk.expiryInterval)) {
-				delete(pk.entries, k)
+	for {
+		select {
+		case <-pk.stop:
+			return
+		case c := <-ticker.C:
+			pk.mu.Lock()
+			for k, v := range pk.entries {
+				if pk.droppable(v, c) {
+					delete(pk.entries, k)
+					pk.evictions.Add(1)
+				}
 			}
+			pk.mu.Unlock()
 		}
-		pk.mu.Unlock()
 	}
 }
 
-func NewPokeCache(interval time.Duration) *PokeCache {
+func (pk *PokeCache) expired(cacheEntry pokeCacheEntry, now time.Time) bool {
+	return now.After(cacheEntry.createdAt.Add(pk.expiryInterval))
+}
+
+// Expired but still within the max-stale window.
+func (pk *PokeCache) stale(cacheEntry pokeCacheEntry, now time.Time) bool {
+	return pk.expired(cacheEntry, now) && !now.After(cacheEntry.createdAt.Add(pk.expiryInterval+pk.maxStale))
+}
+
+// Expired and of no use for serving stale or revalidation either.
+func (pk *PokeCache) droppable(cacheEntry pokeCacheEntry, now time.Time) bool {
+	return pk.droppableAt(cacheEntry.createdAt, cacheEntry.hasValidator(), now)
+}
+
+// Same as droppable when only the timestamp is at hand, e.g. in the disk index.
+func (pk *PokeCache) droppableAt(createdAt time.Time, validated bool, now time.Time) bool {
+	if pk.offline.Load() {
+		return false
+	}
+	if !now.After(createdAt.Add(pk.expiryInterval + pk.maxStale)) {
+		return false // NOTE: Fresh or stale
+	}
+	return !validated || now.After(createdAt.Add(pk.expiryInterval+pk.retention))
+}
+
+func NewPokeCache(interval time.Duration, opts ...Option) *PokeCache {
 	emptyEntries := make(map[string]pokeCacheEntry)
+	pk := newPokeCache(interval, opts)
+	pk.entries = emptyEntries
+	pk.start()
+	return pk
+}
+
+func newPokeCache(ttl time.Duration, opts []Option) *PokeCache {
 	pk := &PokeCache{
-		entries:        emptyEntries,
-		expiryInterval: interval,
+		compression:    CompressionNone,
+		expiryInterval: ttl,
+		logger:         slog.Default(),
+		retention:      DefaultRetention,
+		reap:           ttl > 0,
+	}
+	for _, opt := range opts {
+		opt(pk)
 	}
-	go (*pk).reapLoop()
 	return pk
 }
 
+func (pk *PokeCache) start() {
+	pk.stop = make(chan struct{})
+	pk.reaperDone = make(chan struct{})
+	if pk.reap {
+		go pk.reapLoop()
+	} else {
+		close(pk.reaperDone)
+	}
+}
+
+// Stops the background reaping and waits for it to end. The cache keeps working
+// afterwards, expired entries are then dropped on read. Safe to call more than once.
+func (pk *PokeCache) Close() error {
+	pk.closeOnce.Do(func() {
+		close(pk.stop)
+		<-pk.reaperDone
+	})
+	return nil
+}
+
+// Same as NewPokeCache but every entry is also written to "dir" and read back from it
+// on a miss, so entries outlive the process. "ttl" applies to both layers and
+// "maxBytes" caps the size of the on-disk entries (zero means no cap).
+func NewPersistentPokeCache(dir string, ttl time.Duration, maxBytes int64, opts ...Option) (*PokeCache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("error, cache TTL must be positive, got %s", ttl)
+	}
+	pk := newPokeCache(ttl, opts)
+	pk.entries = make(map[string]pokeCacheEntry)
+	store, err := openDiskStore(dir, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	pk.store = store
+	pk.start()
+	return pk, nil
+}
+
+// While offline nothing is dropped for its age, Lookup returns expired entries however
+// old they are since there is no newer copy to get. The size limit still applies.
+func (pk *PokeCache) SetOffline(offline bool) {
+	pk.offline.Store(offline)
+}
+
+// Where the cache logs to. Defaults to slog.Default.
+func (pk *PokeCache) SetLogger(logger *slog.Logger) {
+	pk.mu.Lock()
+	defer pk.mu.Unlock()
+	pk.logger = logger
+}
+
+func (pk *PokeCache) log() *slog.Logger {
+	pk.mu.RLock()
+	defer pk.mu.RUnlock()
+	return pk.logger
+}
+
 func DefaultPokeCache() *PokeCache {
 	return NewPokeCache(8 * time.Second)
 }
 
 // "key" is the previous and next field URL names
 func (pk *PokeCache) Add(key string, newData []byte) {
-	log.Println("Adding new cache entry...")
-	pk.mu.Lock()
-	createdAt := time.Now()
+	pk.AddEntry(key, Entry{Val: newData})
+}
+
+// See RevalidatingCache.
+func (pk *PokeCache) AddEntry(key string, entry Entry) {
+	pk.log().Debug("Adding new cache entry...", "key", key)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	stored, encoding := compress(pk.compression, entry.Val)
 	newEntry := pokeCacheEntry{
-		createdAt: createdAt,
-		val:       newData,
+		createdAt:    entry.CreatedAt,
+		val:          stored,
+		encoding:     encoding,
+		size:         len(entry.Val),
+		etag:         entry.ETag,
+		lastModified: entry.LastModified,
 	}
+	pk.mu.Lock()
 	pk.entries[key] = newEntry
 	pk.mu.Unlock()
+	pk.writeToDisk(key, newEntry)
+}
+
+func (pk *PokeCache) writeToDisk(key string, cacheEntry pokeCacheEntry) {
+	if pk.store == nil {
+		return
+	}
+	if err := pk.store.put(newDiskEntry(key, cacheEntry)); err != nil {
+		pk.log().Warn("Failed to write cache entry to disk", "key", key, "error", err)
+	}
 }
 
 func (pk *PokeCache) Get(key string) ([]byte, bool) {
-	log.Println("Getting cache entry....")
+	pk.log().Debug("Getting cache entry....", "key", key)
+	entry, fresh, ok := pk.Lookup(key)
+	switch {
+	case ok && fresh:
+		pk.hits.Add(1)
+		return entry.Val, true
+	case ok && entry.Stale:
+		// NOTE: Still a miss for the caller, who is expected to serve it from Lookup.
+		pk.staleHits.Add(1)
+		pk.log().Debug("Cache entry is stale.", "key", key)
+		return nil, false
+	}
+	pk.misses.Add(1)
+	pk.log().Debug("Cache entry is outdated or does not exist.", "key", key)
+	return nil, false
+}
+
+// Like Get but without logging or counting towards Stats. Meant for looking around the
+// cache, e.g. for completion.
+func (pk *PokeCache) Peek(key string) ([]byte, bool) {
+	entry, fresh, ok := pk.Lookup(key)
+	if !ok || !fresh {
+		return nil, false
+	}
+	return entry.Val, true
+}
+
+// See RevalidatingCache. Entries only on disk are loaded back into memory.
+func (pk *PokeCache) Lookup(key string) (entry Entry, fresh bool, ok bool) {
+	now := time.Now()
 	pk.mu.RLock()
-	defer pk.mu.RUnlock()
 	cacheEntry, ok := pk.entries[key]
+	pk.mu.RUnlock()
+	if ok && pk.droppable(cacheEntry, now) {
+		// NOTE: The reaper did not get to it yet, or there is none.
+		pk.mu.Lock()
+		if current, found := pk.entries[key]; found && pk.droppable(current, now) {
+			delete(pk.entries, key)
+			pk.evictions.Add(1)
+		}
+		pk.mu.Unlock()
+		ok = false
+	}
+	if !ok && pk.store != nil {
+		cacheEntry, ok = pk.getFromDisk(key, now)
+	}
 	if !ok {
-		log.Printf("Cache entry is outdated or does not exist. Entry: %s\n", key)
-		return nil, false
+		return Entry{}, false, false
+	}
+	entry, err := cacheEntry.export()
+	if err != nil {
+		pk.log().Warn("Dropping cache entry that cannot be decompressed", "key", key, "error", err)
+		pk.Delete(key)
+		return Entry{}, false, false
+	}
+	entry.Stale = pk.stale(cacheEntry, now)
+	return entry, !pk.expired(cacheEntry, now), true
+}
+
+// Loads an entry from disk back into memory, keeping its original timestamp so it
+// expires at the same time it would have without the restart. Entries too old to keep
+// in memory are still returned: they stay on disk until a new copy replaces them or
+// the size limit needs the room, so a failed fetch can fall back to them.
+func (pk *PokeCache) getFromDisk(key string, now time.Time) (pokeCacheEntry, bool) {
+	diskData, ok := pk.store.get(key)
+	if !ok {
+		return pokeCacheEntry{}, false
+	}
+	cacheEntry := diskData.cacheEntry()
+	if !pk.droppable(cacheEntry, now) {
+		pk.mu.Lock()
+		pk.entries[key] = cacheEntry
+		pk.mu.Unlock()
+	}
+	return cacheEntry, true
+}
+
+// See RevalidatingCache.
+func (pk *PokeCache) Refresh(key, etag, lastModified string) bool {
+	entry, _, ok := pk.Lookup(key)
+	if !ok {
+		return false
+	}
+	pk.log().Debug("Revalidated cache entry", "key", key)
+	if etag != "" {
+		entry.ETag = etag
+	}
+	if lastModified != "" {
+		entry.LastModified = lastModified
+	}
+	entry.CreatedAt = time.Now()
+	pk.AddEntry(key, entry)
+	pk.revalidations.Add(1)
+	return true
+}
+
+// Every key currently in the cache, on disk included, in no particular order. Expired
+// entries kept for revalidation are included.
+func (pk *PokeCache) Keys() []string {
+	seen := make(map[string]struct{})
+	now := time.Now()
+	pk.mu.RLock()
+	for k, v := range pk.entries {
+		if !pk.droppable(v, now) {
+			seen[k] = struct{}{}
+		}
+	}
+	pk.mu.RUnlock()
+	if pk.store != nil {
+		keep := func(createdAt time.Time, validated bool) bool {
+			return !pk.droppableAt(createdAt, validated, now)
+		}
+		for _, k := range pk.store.keys(keep) {
+			seen[k] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Number of entries, on disk included.
+func (pk *PokeCache) Len() int {
+	return len(pk.Keys())
+}
+
+// Removes "key" from memory and disk. Reports whether it was there.
+func (pk *PokeCache) Delete(key string) bool {
+	pk.mu.Lock()
+	_, found := pk.entries[key]
+	delete(pk.entries, key)
+	pk.mu.Unlock()
+	if pk.store != nil && pk.store.remove(key) {
+		found = true
+	}
+	if found {
+		pk.log().Debug("Deleted cache entry", "key", key)
+	}
+	return found
+}
+
+// Removes every entry from memory and disk. The counters of Stats are kept.
+func (pk *PokeCache) Clear() {
+	pk.mu.Lock()
+	pk.entries = make(map[string]pokeCacheEntry)
+	pk.mu.Unlock()
+	if pk.store != nil {
+		pk.store.clear()
+	}
+	pk.log().Debug("Cleared the cache")
+}
+
+func (pk *PokeCache) Stats() Stats {
+	stats := Stats{
+		Backend:     BackendMemory,
+		Compression: pk.compression,
+		Entries:     pk.Len(),
+		Hits:        pk.hits.Load(),
+		Misses:      pk.misses.Load(),
+		Evictions:   pk.evictions.Load(),
+	}
+	stats.Revalidations = pk.revalidations.Load()
+	stats.StaleHits = pk.staleHits.Load()
+	pk.mu.RLock()
+	stats.MemoryEntries = len(pk.entries)
+	for _, cacheEntry := range pk.entries {
+		stats.MemoryBytes += int64(len(cacheEntry.val))
+		stats.UncompressedBytes += int64(cacheEntry.size)
+	}
+	stats.CompressedBytes = stats.MemoryBytes
+	pk.mu.RUnlock()
+	if pk.store != nil {
+		var diskEvictions int64
+		stats.Backend = BackendDisk
+		stats.MaxBytes = pk.store.maxBytes
+		stats.Persistent = true
+		stats.DiskEntries, stats.DiskBytes, diskEvictions = pk.store.stats()
+		stats.Evictions += diskEvictions
+		// NOTE: Everything in memory is on disk too, so disk is the whole picture.
+		stats.UncompressedBytes, stats.CompressedBytes = pk.store.valueBytes()
 	}
-	return cacheEntry.val, true
+	return stats
 }
@@ -3,6 +3,24 @@ package pokecache
 import "testing"
 import "time"
 import "fmt"
+import "runtime"
+
+// Fails the test if it leaves more goroutines running than it found. Caches have to
+// be closed before the test ends for this to pass.
+func checkGoroutineLeaks(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		// NOTE: Goroutines that were told to stop may need a moment to actually end.
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			t.Errorf("expected %d goroutines after the test, got %d", before, after)
+		}
+	})
+}
 
 func TestAddGet(t *testing.T) {
 	const interval = 5 * time.Second
@@ -23,6 +41,7 @@ func TestAddGet(t *testing.T) {
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
 			cache := NewPokeCache(interval)
+			defer cache.Close()
 			cache.Add(c.key, c.val)
 			val, ok := cache.Get(c.key)
 			if !ok {
@@ -40,7 +59,9 @@ func TestAddGet(t *testing.T) {
 func TestReapLoop(t *testing.T) {
 	const baseTime = 5 * time.Second
 	const waitTime = baseTime + 5*time.Second
+	checkGoroutineLeaks(t)
 	cache := NewPokeCache(baseTime)
+	defer cache.Close()
 	cache.Add("https://example.com", []byte("testdata"))
 
 	_, ok := cache.Get("https://example.com")
@@ -57,3 +78,114 @@ func TestReapLoop(t *testing.T) {
 		return
 	}
 }
+
+func TestStats(t *testing.T) {
+	cache, err := NewPersistentPokeCache(t.TempDir(), time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com/a", []byte("1234"))
+	cache.Add("https://example.com/b", []byte("56"))
+	cache.Get("https://example.com/a")
+	cache.Get("https://example.com/a")
+	cache.Get("https://example.com/c")
+	cache.Peek("https://example.com/c")
+
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.MemoryEntries != 2 || stats.DiskEntries != 2 {
+		t.Errorf("expected 2 entries everywhere, got %+v", stats)
+	}
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("expected 2 hits and 1 miss, got %+v", stats)
+	}
+	if stats.MemoryBytes != 6 || stats.DiskBytes == 0 {
+		t.Errorf("expected 6 bytes in memory and some on disk, got %+v", stats)
+	}
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com/a", []byte("a"))
+	cache.Add("https://example.com/b", []byte("b"))
+	cache.Add("https://example.com/c", []byte("c"))
+
+	if !cache.Delete("https://example.com/a") {
+		t.Errorf("expected Delete to report the entry was there")
+	}
+	if cache.Delete("https://example.com/a") {
+		t.Errorf("expected Delete to report the entry is gone")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries after Delete, got %d", cache.Len())
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Errorf("expected no entries after Clear, got %d", cache.Len())
+	}
+	reopened, err := NewPersistentPokeCache(dir, time.Minute, 0, WithoutReaping())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reopened.Len() != 0 {
+		t.Errorf("expected Clear to empty the disk too, got %v", reopened.Keys())
+	}
+}
+
+func TestCloseStopsReaping(t *testing.T) {
+	checkGoroutineLeaks(t)
+	for range 10 {
+		cache := NewPokeCache(time.Millisecond)
+		cache.Add("https://example.com", []byte("testdata"))
+		cache.Close()
+		cache.Close() // NOTE: Closing twice is fine
+	}
+}
+
+func TestWithoutReapingExpiresOnRead(t *testing.T) {
+	checkGoroutineLeaks(t)
+	const interval = 50 * time.Millisecond
+	cache := NewPokeCache(interval, WithoutReaping())
+	cache.Add("https://example.com", []byte("testdata"))
+	if _, ok := cache.Get("https://example.com"); !ok {
+		t.Errorf("expected to find key")
+	}
+
+	time.Sleep(interval + 10*time.Millisecond)
+
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+	}
+	if stats := cache.Stats(); stats.MemoryEntries != 0 || stats.Evictions != 1 {
+		t.Errorf("expected the expired entry to be dropped on read, got %+v", stats)
+	}
+}
+
+func TestMaxStaleKeepsExpiredEntries(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	cache := NewPokeCache(interval, WithoutReaping(), WithMaxStale(interval))
+	cache.Add("https://example.com", []byte("testdata"))
+
+	time.Sleep(interval + 10*time.Millisecond)
+
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected Get to not serve a stale entry")
+	}
+	entry, fresh, ok := cache.Lookup("https://example.com")
+	if !ok || fresh || !entry.Stale || string(entry.Val) != "testdata" {
+		t.Errorf("expected a stale entry, got %+v fresh=%v ok=%v", entry, fresh, ok)
+	}
+
+	time.Sleep(interval)
+
+	if _, _, ok := cache.Lookup("https://example.com"); ok {
+		t.Errorf("expected the entry to be gone after the max-stale window")
+	}
+	if stats := cache.Stats(); stats.StaleHits != 1 || stats.Misses != 0 {
+		t.Errorf("expected 1 stale hit and no misses, got %+v", stats)
+	}
+}
@@ -1,28 +1,234 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/uncomfyhalomacro/pokedexcli/internal/core"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokecache"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 )
 
+// Environment variable used when --api-url is not given.
+const apiURLEnv = "POKEDEX_API_URL"
+
 func main() {
+	apiURL := flag.String("api-url", "", "base URL of the PokeAPI instance (env "+apiURLEnv+", default "+pokeapi.DefaultBaseURL+")")
+	cacheDir := flag.String("cache-dir", "", "directory of the on-disk cache (default $XDG_CACHE_HOME/pokedexcli)")
+	cacheTTL := flag.Duration("cache-ttl", 24*time.Hour, "how long cached responses stay valid, must be positive")
+	cacheBackend := flag.String("cache", pokecache.BackendDisk, "where responses are cached: disk, memory, lru (memory with a size limit) or none")
+	maxStale := flag.Duration("max-stale", 0, "serve expired responses up to this long past --cache-ttl while refreshing them in the background, 0 to always wait for fresh data")
+	cacheMaxSize := flag.Int64("cache-max-size", 64, "size limit of the disk or lru cache in MiB, 0 for no limit")
+	cacheCompression := flag.String("cache-compression", pokecache.CompressionNone, "compress cached responses in memory and on disk: none or gzip")
+	noDiskCache := flag.Bool("no-disk-cache", false, "same as --cache memory")
+	saveFile := flag.String("save-file", "", "file your Pokedex is saved to (default $XDG_DATA_HOME/pokedexcli/pokedex.json)")
+	historyFile := flag.String("history-file", "", "file the prompt history is kept in (default $XDG_STATE_HOME/pokedexcli/history)")
+	timeout := flag.Duration("timeout", 30*time.Second, "give up on a single API request after this long, 0 to wait forever")
+	retries := flag.Int("retries", pokeapi.DefaultRetryPolicy().MaxRetries, "how often a request is retried after a 429 or 5xx response")
+	rateLimit := flag.Float64("rate-limit", 5, "most API requests per second, 0 for no limit")
+	pageSize := flag.Int("page-size", core.DefaultPageSize, "number of location areas `map` shows at once")
+	concurrency := flag.Int("concurrency", core.DefaultConcurrency, "how many areas or pokemons `explore` and `inspect` fetch at once")
+	offline := flag.Bool("offline", false, "never touch the network, only serve cached responses")
+	output := flag.String("output", string(core.FormatText), "output format: text, json, yaml or table")
+	scriptFile := flag.String("f", "", "run the commands in this file, one per line, and exit")
+	failFast := flag.Bool("fail-fast", false, "stop a script at the first failing command")
+	debug := flag.Bool("debug", false, "log every cache lookup and other details")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command [args...]]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Without a command, commands are read from the prompt, or from stdin when it is not a terminal.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	outputFormat, err := core.ParseFormat(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	baseURL, err := resolveAPIURL(*apiURL, os.Getenv(apiURLEnv))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	logLevel := slog.LevelInfo
+	if *debug {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	if *noDiskCache && *cacheBackend == pokecache.BackendDisk {
+		*cacheBackend = pokecache.BackendMemory
+	}
+	compression, err := pokecache.ParseCompression(*cacheCompression)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	cache, err := newCache(logger, *cacheBackend, *cacheDir, cacheOptions{ttl: *cacheTTL, maxStale: *maxStale, compression: compression}, *cacheMaxSize)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	defer cache.Close()
+	retryPolicy := pokeapi.DefaultRetryPolicy()
+	retryPolicy.MaxRetries = max(*retries, 0)
+	transport := pokeapi.NewRetryTransport(http.DefaultTransport, retryPolicy, pokeapi.NewRateLimiter(*rateLimit, int(max(*rateLimit, 1))))
+	transport.Logger = logger
+	client := pokeapi.NewClient(baseURL, &http.Client{Transport: transport}, cache)
+	client.SetOffline(*offline)
+	client.SetTimeout(*timeout)
+	client.SetLogger(logger)
+	if *offline && *cacheBackend != pokecache.BackendDisk {
+		logger.Warn("Offline mode without the disk cache has nothing to serve")
+	}
+
+	if *saveFile == "" {
+		*saveFile, err = core.DefaultSavePath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error, could not find a place for the save file, use --save-file:", err)
+			os.Exit(exitUsage)
+		}
+	}
+	pokedex, err := core.LoadPokedex(*saveFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+
 	config := &core.Config{
-		Next:     "",
-		Previous: "",
-	}
-	userInput := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Print("Pokedex > ")
-		if userInput.Scan() {
-			receivedInput := userInput.Text()
-			cleanedInput := core.CleanInput(receivedInput)
-			firstWord := cleanedInput[0]
-			err := core.RunSupportedCommand(config, firstWord, cleanedInput[1:]...)
-			if err != nil {
-				fmt.Println(err)
-			}
+		Pages:       core.NewPaginator(*pageSize),
+		Concurrency: *concurrency,
+		Client:      client,
+		Pokedex:     pokedex,
+		Output:      outputFormat,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Logger:      logger,
+	}
+
+	// NOTE: Outside the REPL Ctrl-C cancels whatever is running and ends the run.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	switch {
+	case flag.NArg() > 0:
+		code := runOnce(ctx, config, flag.Args())
+		waitForRefreshes(ctx, client)
+		os.Exit(code)
+	case *scriptFile != "":
+		f, err := os.Open(*scriptFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error, failed to open script:", err)
+			os.Exit(exitUsage)
+		}
+		defer f.Close()
+		code := runScript(ctx, config, f, *scriptFile, *failFast)
+		waitForRefreshes(ctx, client)
+		os.Exit(code)
+	case !stdinIsTerminal():
+		code := runScript(ctx, config, os.Stdin, "stdin", *failFast)
+		waitForRefreshes(ctx, client)
+		os.Exit(code)
+	}
+
+	if *historyFile == "" {
+		if defaultPath, err := defaultHistoryPath(); err == nil {
+			*historyFile = defaultPath
 		}
 	}
+	stop() // NOTE: The REPL handles Ctrl-C per command
+	startRepl(config, *historyFile)
+	waitForRefreshes(context.Background(), client)
+}
+
+// How long exiting waits for background refreshes of stale entries.
+const refreshGracePeriod = 5 * time.Second
+
+// Stale entries served by this run are refreshed in the background. Let that land in
+// the cache for the next run, but never hang on a slow API, and not at all after Ctrl-C.
+func waitForRefreshes(ctx context.Context, client *pokeapi.Client) {
+	ctx, cancel := context.WithTimeout(ctx, refreshGracePeriod)
+	defer cancel()
+	client.Wait(ctx)
+}
+
+// The flag wins over the environment variable, which wins over the public API.
+func resolveAPIURL(flagValue, envValue string) (string, error) {
+	apiURL := pokeapi.DefaultBaseURL
+	if envValue != "" {
+		apiURL = envValue
+	}
+	if flagValue != "" {
+		apiURL = flagValue
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("error, invalid API URL %q: expected an http(s) URL", apiURL)
+	}
+	return apiURL, nil
+}
+
+// Settings shared by every cache backend.
+type cacheOptions struct {
+	ttl         time.Duration
+	maxStale    time.Duration
+	compression string
+}
+
+func (o cacheOptions) pokeCacheOptions() []pokecache.Option {
+	return []pokecache.Option{pokecache.WithMaxStale(o.maxStale), pokecache.WithCompression(o.compression)}
+}
+
+// Builds the cache picked with --cache. The disk cache falls back to the in-memory one
+// if it cannot be opened. The CLI still works without it, it just has to fetch
+// everything again next time.
+func newCache(logger *slog.Logger, backend, dir string, opts cacheOptions, maxSizeMiB int64) (pokecache.Cache, error) {
+	// NOTE: Every backend reads a TTL of zero differently, none of them as "no caching".
+	if backend != pokecache.BackendNone && opts.ttl <= 0 {
+		return nil, fmt.Errorf("error, --cache-ttl must be positive, got %s, use --cache %s to disable caching", opts.ttl, pokecache.BackendNone)
+	}
+	var cache pokecache.Cache
+	switch backend {
+	case pokecache.BackendNone:
+		return pokecache.NopCache{}, nil
+	case pokecache.BackendMemory:
+		cache = pokecache.NewPokeCache(opts.ttl, opts.pokeCacheOptions()...)
+	case pokecache.BackendLRU:
+		lru := pokecache.NewLRUCache(maxSizeMiB*1024*1024, opts.ttl)
+		lru.SetMaxStale(opts.maxStale)
+		lru.SetCompression(opts.compression)
+		cache = lru
+	case pokecache.BackendDisk:
+		cache = newDiskCache(logger, dir, opts, maxSizeMiB)
+	default:
+		return nil, fmt.Errorf("error, unknown cache %q, expected one of %s", backend, strings.Join(pokecache.Backends, ", "))
+	}
+	if withLogger, ok := cache.(interface{ SetLogger(*slog.Logger) }); ok {
+		withLogger.SetLogger(logger)
+	}
+	return cache, nil
+}
+
+func newDiskCache(logger *slog.Logger, dir string, opts cacheOptions, maxSizeMiB int64) pokecache.Cache {
+	if dir == "" {
+		defaultDir, err := pokecache.DefaultCacheDir()
+		if err != nil {
+			logger.Warn("No cache directory available, using an in-memory cache", "error", err)
+			return pokecache.NewPokeCache(opts.ttl, opts.pokeCacheOptions()...)
+		}
+		dir = defaultDir
+	}
+	cache, err := pokecache.NewPersistentPokeCache(dir, opts.ttl, maxSizeMiB*1024*1024, opts.pokeCacheOptions()...)
+	if err != nil {
+		logger.Warn("Using an in-memory cache", "error", err)
+		return pokecache.NewPokeCache(opts.ttl, opts.pokeCacheOptions()...)
+	}
+	return cache
 }
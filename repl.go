@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/peterh/liner"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/core"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+const prompt = "Pokedex > "
+
+// Reads commands until `exit` or Ctrl-D. History is loaded from and saved to
+// "historyPath" unless it is empty.
+func startRepl(config *core.Config, historyPath string) {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(func(input string, pos int) (string, []string, string) {
+		return completeWord(config, input, pos)
+	})
+
+	if historyPath != "" {
+		loadHistory(line, historyPath)
+		defer saveHistory(config, line, historyPath)
+	}
+
+	// NOTE: At the prompt liner reads Ctrl-C as a key. While a command runs the
+	// terminal is back in cooked mode and Ctrl-C arrives as a signal instead.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	for {
+		receivedInput, err := line.Prompt(prompt)
+		if errors.Is(err, liner.ErrPromptAborted) {
+			// NOTE: Ctrl-C only throws away what was typed so far.
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(config.Out())
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(config.Err(), "error, failed to read input:", err)
+			return
+		}
+
+		cleanedInput := core.SplitInput(receivedInput)
+		if len(cleanedInput) == 0 {
+			continue
+		}
+		line.AppendHistory(strings.TrimSpace(receivedInput))
+
+		firstWord := cleanedInput[0]
+		err = runCancellable(interrupts, func(ctx context.Context) error {
+			return core.RunSupportedCommand(ctx, config, firstWord, cleanedInput[1:]...)
+		})
+		if errors.Is(err, core.ErrExit) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(config.Err(), errorMessage(err))
+		}
+	}
+}
+
+// Runs "run" with a context that is cancelled by the next interrupt.
+func runCancellable(interrupts <-chan os.Signal, run func(ctx context.Context) error) error {
+	// NOTE: Drop an interrupt that came in between two commands.
+	select {
+	case <-interrupts:
+	default:
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-done:
+		}
+	}()
+	return run(ctx)
+}
+
+// Completes the word under the cursor. Everything before it is handed to the
+// registry so it knows which command's arguments are being completed.
+func completeWord(config *core.Config, input string, pos int) (string, []string, string) {
+	head, tail := input[:pos], input[pos:]
+	start := strings.LastIndexAny(head, " \t") + 1
+	words := core.CleanInput(head[:start])
+	prefix := strings.ToLower(head[start:])
+
+	var completions []string
+	for _, candidate := range core.DefaultRegistry.Complete(config, words, prefix) {
+		completions = append(completions, candidate+" ")
+	}
+	return head[:start], completions, tail
+}
+
+func loadHistory(line *liner.State, historyPath string) {
+	f, err := os.Open(historyPath)
+	if err != nil {
+		return // NOTE: No history yet
+	}
+	defer f.Close()
+	line.ReadHistory(f)
+}
+
+func saveHistory(config *core.Config, line *liner.State, historyPath string) {
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0o755); err != nil {
+		config.Log().Warn("Failed to save history", "path", historyPath, "error", err)
+		return
+	}
+	f, err := os.Create(historyPath)
+	if err != nil {
+		config.Log().Warn("Failed to save history", "path", historyPath, "error", err)
+		return
+	}
+	defer f.Close()
+	if _, err := line.WriteHistory(f); err != nil {
+		config.Log().Warn("Failed to save history", "path", historyPath, "error", err)
+	}
+}
+
+// Default location of the history file. Honours $XDG_STATE_HOME.
+func defaultHistoryPath() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "pokedexcli", "history"), nil
+}
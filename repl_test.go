@@ -37,3 +37,11 @@ like   with more space!
 		}
 	}
 }
+
+func TestSplitInput(t *testing.T) {
+	actual := core.SplitInput("  LOAD  ~/Profiles/Misty.json ")
+	expected := []string{"load", "~/Profiles/Misty.json"}
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/core"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes of one-shot and script runs.
+const (
+	exitOK          = 0
+	exitFailure     = 1 // NOTE: A command ran and failed for any other reason
+	exitUsage       = 2 // NOTE: Unknown command, wrong arguments or bad flags
+	exitNotFound    = 3 // NOTE: The API does not know the pokemon or area
+	exitNetwork     = 4 // NOTE: The API could not be reached, or not offline
+	exitRateLimited = 5
+	exitDecode      = 6 // NOTE: The API (or the cache) returned something unexpected
+	exitEmpty       = 7 // NOTE: Nothing to show, e.g. an empty Pokedex
+	exitInterrupted = 130
+)
+
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, core.ErrExit):
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, core.ErrCommandNotFound), errors.Is(err, core.ErrInvalidArgs):
+		return exitUsage
+	case errors.Is(err, pokeapi.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, pokeapi.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, pokeapi.ErrNetwork), errors.Is(err, pokeapi.ErrNotCached):
+		return exitNetwork
+	case errors.Is(err, pokeapi.ErrDecode):
+		return exitDecode
+	case errors.Is(err, core.ErrEmptyResult):
+		return exitEmpty
+	default:
+		return exitFailure
+	}
+}
+
+// What the REPL and scripts print for a failed command: the error itself and, for
+// the kinds of errors a user can do something about, a hint on what to do.
+func errorMessage(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "Cancelled."
+	}
+	message := strings.TrimRight(err.Error(), "\n")
+	var usageErr *core.UsageError
+	var statusErr *pokeapi.StatusError
+	switch {
+	case errors.As(err, &usageErr):
+		return fmt.Sprintf("%s\nUsage: %s", message, usageErr.Usage)
+	case errors.Is(err, core.ErrCommandNotFound):
+		return message + "\nType `help` to see the available commands."
+	case errors.Is(err, pokeapi.ErrRateLimited) && errors.As(err, &statusErr) && statusErr.RetryAfter > 0:
+		return fmt.Sprintf("%s\nThe API is limiting requests, try again in %s.", message, statusErr.RetryAfter)
+	case errors.Is(err, pokeapi.ErrRateLimited):
+		return message + "\nThe API is limiting requests, try again later."
+	case errors.Is(err, pokeapi.ErrNotCached):
+		return message + "\nRun it once without --offline to cache it."
+	case errors.Is(err, context.DeadlineExceeded):
+		return message + "\nThe API took too long to answer, see --timeout."
+	case errors.Is(err, pokeapi.ErrNetwork):
+		return message + "\nCheck your connection, or use --offline to work from the cache."
+	}
+	return message
+}
+
+// Runs `pokedexcli <command> [args...]`.
+func runOnce(ctx context.Context, config *core.Config, args []string) int {
+	if len(args) == 0 {
+		return exitOK
+	}
+	// NOTE: The shell already split the arguments, quoted ones may contain spaces.
+	err := core.RunSupportedCommand(ctx, config, strings.ToLower(args[0]), args[1:]...)
+	if err != nil && !errors.Is(err, core.ErrExit) {
+		fmt.Fprintln(config.Err(), errorMessage(err))
+	}
+	return exitCode(err)
+}
+
+// Runs one command per line. Empty lines and lines starting with `#` are skipped.
+// Without "failFast" every line runs and the exit code is the one of the last
+// failure. `exit` or cancelling "ctx" stops the script early.
+func runScript(ctx context.Context, config *core.Config, script io.Reader, name string, failFast bool) int {
+	code := exitOK
+	scanner := bufio.NewScanner(script)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cleanedInput := core.SplitInput(line)
+		err := core.RunSupportedCommand(ctx, config, cleanedInput[0], cleanedInput[1:]...)
+		if errors.Is(err, core.ErrExit) {
+			return code
+		}
+		if err != nil {
+			fmt.Fprintf(config.Err(), "%s:%d: %s\n", name, lineNumber, errorMessage(err))
+			code = exitCode(err)
+			if failFast {
+				return code
+			}
+		}
+		if ctx.Err() != nil {
+			return exitInterrupted
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(config.Err(), "error, failed to read %s: %v\n", name, err)
+		return exitFailure
+	}
+	return code
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uncomfyhalomacro/pokedexcli/internal/core"
+	"github.com/uncomfyhalomacro/pokedexcli/internal/pokeapi"
+)
+
+func TestRunScript(t *testing.T) {
+	config := &core.Config{Stdout: io.Discard, Stderr: io.Discard}
+	testCases := []struct {
+		script   string
+		failFast bool
+		expected int
+	}{
+		{script: "# only a comment\n\n   \n", expected: exitOK},
+		{script: "help\nnope\nhelp\n", expected: exitUsage},
+		{script: "nope\nexit\nnope\n", failFast: false, expected: exitUsage},
+		{script: "exit\nnope\n", expected: exitOK},
+		{script: "help catch pikachu\nhelp\n", failFast: true, expected: exitUsage},
+	}
+
+	for _, testCase := range testCases {
+		actual := runScript(context.Background(), config, strings.NewReader(testCase.script), "test", testCase.failFast)
+		if actual != testCase.expected {
+			t.Errorf("script %q: expected exit code %d, got %d", testCase.script, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRunOnceKeepsQuotedArguments(t *testing.T) {
+	var out bytes.Buffer
+	config := &core.Config{Stdout: &out, Stderr: io.Discard}
+	path := filepath.Join(t.TempDir(), "My Saves", "x.json")
+	if actual := runOnce(context.Background(), config, []string{"LOAD", path}); actual != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, actual)
+	}
+	if !strings.Contains(out.String(), path) {
+		t.Errorf("expected the path with its space, got %q", out.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected int
+	}{
+		{err: nil, expected: exitOK},
+		{err: core.ErrExit, expected: exitOK},
+		{err: &core.UsageError{Command: "catch", Usage: "catch <pokemon>"}, expected: exitUsage},
+		{err: fmt.Errorf("error, two is not a page number: %w", core.ErrInvalidArgs), expected: exitUsage},
+		{err: fmt.Errorf("error, could not catch x: %w", &pokeapi.StatusError{StatusCode: 404}), expected: exitNotFound},
+		{err: &pokeapi.StatusError{StatusCode: 429}, expected: exitRateLimited},
+		{err: &pokeapi.StatusError{StatusCode: 503}, expected: exitFailure},
+		{err: &pokeapi.NetworkError{Err: errors.New("connection refused")}, expected: exitNetwork},
+		{err: &pokeapi.NetworkError{Err: context.Canceled}, expected: exitInterrupted},
+		{err: fmt.Errorf("x is not available offline: %w", pokeapi.ErrNotCached), expected: exitNetwork},
+		{err: &pokeapi.DecodeError{Err: errors.New("unexpected EOF")}, expected: exitDecode},
+		{err: fmt.Errorf("error, %w: your Pokedex is empty", core.ErrEmptyResult), expected: exitEmpty},
+		{err: errors.New("error, failed to write save file"), expected: exitFailure},
+	}
+
+	for _, testCase := range testCases {
+		if actual := exitCode(testCase.err); actual != testCase.expected {
+			t.Errorf("%v: expected exit code %d, got %d", testCase.err, testCase.expected, actual)
+		}
+	}
+}